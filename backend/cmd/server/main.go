@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -13,38 +21,155 @@ import (
 
 	"multistream/backend/internal/config"
 	"multistream/backend/internal/handlers"
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/metrics"
 	"multistream/backend/internal/services"
+	"multistream/backend/internal/tracing"
 )
 
+// shutdownTimeout bounds how long in-flight requests and the last spans
+// get to finish once the backend is told to stop
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	// Load .env file from project root (one level up from backend)
 	envPath := filepath.Join("..", ".env")
-	if err := godotenv.Load(envPath); err != nil {
-		log.Println("⚠️  No .env file found, using environment variables")
-	}
+	envErr := godotenv.Load(envPath)
 
 	// Load configuration
 	cfg := config.Load()
 
+	// Logging
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	if envErr != nil {
+		slog.Warn("⚠️  No .env file found, using environment variables")
+	}
+
+	// Tracing; spans are batched, so only the last few are lost if the
+	// process is killed
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.OTLPEndpoint, cfg.TraceSampleRatio)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
 	// Initialize services
 	youtubeService := services.NewYouTubeService(cfg.YouTubeAPIKey)
 	kickService := services.NewKickService()
+	kickService.ConfigureSearch(cfg.KickSearchEndpoints, cfg.KickEndpointCooldown)
+	if cfg.KickProvider == services.KickProviderOfficial {
+		if official := services.NewKickOfficialClient(cfg.KickClientID, cfg.KickClientSecret); official.Configured() {
+			kickService.Official = official
+		} else {
+			slog.Warn("⚠️  KICK_PROVIDER=official needs KICK_CLIENT_ID and KICK_CLIENT_SECRET, using the unofficial API")
+		}
+	}
+	twitchService := services.NewTwitchService(cfg.TwitchClientID, cfg.TwitchClientSecret)
+	resilience := services.NewResilience(cfg.ProviderMaxRetries, cfg.ProviderRetryBackoff, cfg.BreakerFailureThreshold, cfg.BreakerOpenTimeout)
+	resilience.Wrap("youtube", youtubeService.Client)
+	resilience.Wrap("kick", kickService.Client)
+	if kickService.Official != nil {
+		resilience.Wrap("kick-official", kickService.Official.Client)
+	}
+	resilience.Wrap("twitch", twitchService.Client)
+	eventBus := services.NewEventBus()
+	followService := services.NewFollowService(youtubeService, kickService, twitchService, eventBus, cfg.DataDir, cfg.FollowRefreshInterval)
+	webhookService := services.NewWebhookService(followService, cfg.FrontendURL, cfg.DataDir, cfg.WebhookMaxAttempts, cfg.WebhookRetryBackoff)
+	webhookService.AllowPrivateNetworks = cfg.WebhookAllowPrivate
+	scheduleService := services.NewScheduleService(youtubeService, twitchService, followService, cfg.DataDir, cfg.ScheduleRefreshInterval)
+	channelIndex := services.NewChannelIndex(followService, cfg.DataDir)
+	youtubeService.Observer = channelIndex.Observe
+	kickService.Observer = channelIndex.Observe
+	kickService.Channels = channelIndex
+	twitchService.Observer = channelIndex.Observe
+	searchService := services.NewSearchService(youtubeService, kickService, twitchService, cfg.SearchCursorSecret, cfg.DataDir)
+	searchService.Channels = channelIndex
+	topService := services.NewTopService(youtubeService, kickService, twitchService, cfg.TopCacheTTL)
+	categoryService := services.NewCategoryService(youtubeService, kickService, twitchService, cfg.CategoryCacheTTL)
+	viewerRecorder := services.NewViewerRecorder(youtubeService, kickService, twitchService, followService, cfg.DataDir, cfg.ViewerSampleInterval, cfg.ViewerRawRetention, cfg.ViewerHistoryRetention)
+	healthService := services.NewHealthService(cfg.HealthCheckTTL)
+	healthService.Resilience = resilience
+	healthService.Register("storage", true, services.StorageHealth(cfg.DataDir))
+	healthService.Register("cache", false, services.CacheHealth(topService, categoryService))
+	healthService.Register("youtube", false, youtubeService.CheckHealth)
+	healthService.Register("kick", false, kickService.CheckHealth)
+	healthService.Register("twitch", false, twitchService.CheckHealth)
+	feedService := services.NewFeedService(scheduleService, followService, cfg.FrontendURL, cfg.DataDir)
+	pushService, err := services.NewPushService(cfg.VAPIDSubject, cfg.FrontendURL, cfg.DataDir)
+	if err != nil {
+		fatal("Failed to initialize Web Push", err)
+	}
+
+	// Route stream events to notification sinks
+	eventBus.Subscribe(webhookService.HandleEvent)
+	eventBus.Subscribe(pushService.HandleEvent)
+	eventBus.Subscribe(scheduleService.HandleEvent)
+
+	// Push YouTube go-live through WebSub when we are reachable from the hub
+	var websubService *services.WebSubService
+	if cfg.PublicURL != "" && cfg.YouTubeAPIKey != "" {
+		callbackURL := strings.TrimRight(cfg.PublicURL, "/") + "/api/v1/websub/youtube"
+		websubService = services.NewWebSubService(youtubeService, followService, cfg.WebSubHubURL, callbackURL, cfg.DataDir, cfg.WebSubLease)
+		websubService.Schedule = scheduleService
+		followService.AddPushSource(websubService.IsActive)
+	}
+
+	// Push Twitch online/offline through EventSub
+	var eventsubService *services.EventSubService
+	if cfg.PublicURL != "" && twitchService.Configured() {
+		callbackURL := strings.TrimRight(cfg.PublicURL, "/") + "/api/v1/eventsub/twitch"
+		eventsubService = services.NewEventSubService(twitchService, followService, callbackURL, cfg.TwitchEventSubSecret, cfg.DataDir)
+		followService.AddPushSource(eventsubService.IsActive)
+	}
+
+	// Start background workers; they stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go followService.Run(ctx)
+	go scheduleService.Run(ctx)
+	go viewerRecorder.Run(ctx)
+	go channelIndex.Run(ctx)
+	go webhookService.Run(ctx)
+	if websubService != nil {
+		go websubService.Run(ctx)
+	}
+	if eventsubService != nil {
+		go eventsubService.Run(ctx)
+	}
 
 	// Initialize handlers
-	searchHandler := handlers.NewSearchHandler(youtubeService, kickService)
-	streamHandler := handlers.NewStreamHandler(youtubeService, kickService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	suggestHandler := handlers.NewSuggestHandler(channelIndex)
+	channelIndexHandler := handlers.NewChannelIndexHandler(channelIndex)
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(kickService)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	streamHandler := handlers.NewStreamHandler(youtubeService, kickService, twitchService)
+	viewerHandler := handlers.NewViewerHandler(viewerRecorder)
+	topHandler := handlers.NewTopHandler(topService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	followHandler := handlers.NewFollowHandler(followService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	feedHandler := handlers.NewFeedHandler(feedService, cfg.PublicURL)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	pushHandler := handlers.NewPushHandler(pushService)
+	websubHandler := handlers.NewWebSubHandler(websubService)
+	eventsubHandler := handlers.NewEventSubHandler(eventsubService)
 
 	// Create router
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-Id", "traceparent", "tracestate"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -56,20 +181,64 @@ func main() {
 			"name":    "MultiStream API",
 			"version": "1.0.0",
 			"endpoints": []string{
-				"GET /api/v1/search?platform={platform}&query={query}",
+				"GET /api/v1/search?platform={platform}&query={query}&limit={limit}&state={state}&live={bool}&min_viewers={n}&max_viewers={n}&language={code}&category={name}&verified={bool}&exclude_mature={bool}&sort={sort}",
+				"GET /api/v1/suggest?q={prefix}&platform={platform}&limit={limit}",
+				"GET /api/v1/top?platform={platform}&category={category}&language={language}&page={page}&limit={limit}",
+				"GET /api/v1/categories?platform={platform}&query={query}",
+				"GET /api/v1/categories/{id}",
+				"GET /api/v1/categories/{id}/streams?language={language}&limit={limit}",
 				"GET /api/v1/stream/{platform}/{id}",
+				"GET /api/v1/stream/{platform}/{id}/history?from={from}&to={to}&step={step}",
+				"GET /api/v1/follows",
+				"POST /api/v1/follows",
+				"GET /api/v1/follows/live",
+				"PATCH /api/v1/follows/{platform}/{channel}",
+				"DELETE /api/v1/follows/{platform}/{channel}",
+				"GET /api/v1/schedule",
+				"GET /api/v1/feeds/tokens",
+				"POST /api/v1/feeds/tokens",
+				"DELETE /api/v1/feeds/tokens/{id}",
+				"GET /feeds/schedule.ics?token={token}",
+				"GET /feeds/live.atom?token={token}",
+				"GET /feeds/live.rss?token={token}",
+				"GET /api/v1/notifications/webhooks",
+				"POST /api/v1/notifications/webhooks",
+				"GET /api/v1/notifications/webhooks/{id}",
+				"DELETE /api/v1/notifications/webhooks/{id}",
+				"POST /api/v1/notifications/webhooks/{id}/test",
+				"GET /api/v1/notifications/webhooks/{id}/deliveries",
+				"POST /api/v1/notifications/webhooks/{id}/deliveries/{deliveryID}/replay",
+				"GET /api/v1/push/vapid-public-key",
+				"POST /api/v1/push/subscriptions",
+				"DELETE /api/v1/push/subscriptions/{id}",
+				"GET /api/v1/websub/subscriptions",
+				"GET /api/v1/eventsub/subscriptions",
+				"GET /api/v1/admin/channel-index",
+				"POST /api/v1/admin/channel-index/rebuild",
+				"GET /api/v1/diagnostics/kick/endpoints",
 				"GET /api/health",
+				"GET /api/health/live",
+				"GET /api/health/ready",
+				"GET /metrics",
 			},
+			"search": handlers.SearchParameters,
 		})
 	})
 
+	// Prometheus metrics
+	r.Handle("/metrics", metrics.Handler())
+
+	// Subscribable feeds, authenticated by feed token
+	r.Get("/feeds/schedule.ics", feedHandler.Calendar)
+	r.Get("/feeds/live.atom", feedHandler.LiveAtom)
+	r.Get("/feeds/live.rss", feedHandler.LiveRSS)
+
 	// API routes
 	r.Route("/api", func(r chi.Router) {
-		// Health check
-		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-		})
+		// Health checks; /health is kept for older probes and reports readiness
+		r.Get("/health", healthHandler.Ready)
+		r.Get("/health/live", healthHandler.Live)
+		r.Get("/health/ready", healthHandler.Ready)
 
 		// Legacy hello endpoint
 		r.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
@@ -83,24 +252,141 @@ func main() {
 		// V1 API
 		r.Route("/v1", func(r chi.Router) {
 			r.Get("/search", searchHandler.Search)
+			r.Get("/suggest", suggestHandler.Suggest)
+			r.Get("/top", topHandler.List)
+			r.Get("/categories", categoryHandler.List)
+			r.Get("/categories/{id}", categoryHandler.Get)
+			r.Get("/categories/{id}/streams", categoryHandler.Streams)
 			r.Get("/stream/{platform}/{id}", streamHandler.GetStream)
+			r.Get("/stream/{platform}/{id}/history", viewerHandler.History)
+
+			// Followed channels
+			r.Get("/follows", followHandler.List)
+			r.Post("/follows", followHandler.Add)
+			r.Get("/follows/live", followHandler.Live)
+			r.Patch("/follows/{platform}/{channel}", followHandler.Update)
+			r.Delete("/follows/{platform}/{channel}", followHandler.Remove)
+			r.Get("/schedule", scheduleHandler.List)
+
+			// Feed tokens
+			r.Get("/feeds/tokens", feedHandler.ListTokens)
+			r.Post("/feeds/tokens", feedHandler.CreateToken)
+			r.Delete("/feeds/tokens/{id}", feedHandler.DeleteToken)
+
+			// Notifications
+			r.Route("/notifications/webhooks", func(r chi.Router) {
+				r.Get("/", webhookHandler.List)
+				r.Post("/", webhookHandler.Create)
+				r.Get("/{id}", webhookHandler.Get)
+				r.Delete("/{id}", webhookHandler.Delete)
+				r.Post("/{id}/test", webhookHandler.Test)
+				r.Get("/{id}/deliveries", webhookHandler.Deliveries)
+				r.Post("/{id}/deliveries/{deliveryID}/replay", webhookHandler.Replay)
+			})
+
+			// Web Push
+			r.Get("/push/vapid-public-key", pushHandler.PublicKey)
+			r.Post("/push/subscriptions", pushHandler.Subscribe)
+			r.Delete("/push/subscriptions/{id}", pushHandler.Unsubscribe)
+
+			// WebSub hub callbacks
+			if websubService != nil {
+				r.Get("/websub/subscriptions", websubHandler.Subscriptions)
+				r.Get("/websub/youtube/{channel}", websubHandler.Verify)
+				r.Post("/websub/youtube/{channel}", websubHandler.Notify)
+			}
+
+			// Twitch EventSub callbacks
+			if eventsubService != nil {
+				r.Get("/eventsub/subscriptions", eventsubHandler.Subscriptions)
+				r.Post("/eventsub/twitch", eventsubHandler.Callback)
+			}
+
+			// Local channel index
+			r.Get("/admin/channel-index", channelIndexHandler.Stats)
+			r.Post("/admin/channel-index/rebuild", channelIndexHandler.Rebuild)
+
+			// Upstream diagnostics
+			r.Get("/diagnostics/kick/endpoints", diagnosticsHandler.KickEndpoints)
 		})
 	})
 
 	// Start server
 	addr := ":" + cfg.Port
-	log.Printf("🚀 MultiStream Backend started on http://localhost%s", addr)
-	log.Printf("📺 YouTube API: %s", boolToStatus(cfg.YouTubeAPIKey != ""))
-	log.Printf("🟢 Kick API: enabled (unofficial)")
+	slog.Info("🚀 MultiStream Backend started", "url", "http://localhost"+addr)
+	slog.Info("📺 YouTube API", "status", boolToStatus(cfg.YouTubeAPIKey != ""))
+	slog.Info("🟢 Kick API", "status", kickStatus(kickService.Official != nil))
+	slog.Info("🟣 Twitch API", "status", twitchStatus(twitchService.Configured()))
+	slog.Info("📡 YouTube WebSub", "status", boolToEnabled(websubService != nil))
+	slog.Info("📡 Twitch EventSub", "status", boolToEnabled(eventsubService != nil))
+	slog.Info("🔭 Tracing", "status", tracingStatus(cfg.OTLPEndpoint))
+
+	server := &http.Server{Addr: addr, Handler: r}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		shutdownTracing(context.Background())
+		fatal("Server stopped", err)
+	case <-ctx.Done():
+	}
 
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatal(err)
+	// Stop accepting connections, let requests in flight finish, then flush
+	// the spans they produced
+	slog.Info("Shutting down", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to drain connections", "error", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Server stopped", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
 	}
 }
 
+// fatal logs an error that stops the backend and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func boolToStatus(b bool) string {
 	if b {
 		return "configured"
 	}
 	return "not configured (set YOUTUBE_API_KEY)"
 }
+
+func boolToEnabled(b bool) string {
+	if b {
+		return "enabled"
+	}
+	return "disabled (set PUBLIC_URL)"
+}
+
+func twitchStatus(b bool) string {
+	if b {
+		return "configured"
+	}
+	return "not configured (set TWITCH_CLIENT_ID and TWITCH_CLIENT_SECRET)"
+}
+
+func kickStatus(official bool) string {
+	if official {
+		return "official (unofficial for search and categories)"
+	}
+	return "unofficial"
+}
+
+func tracingStatus(endpoint string) string {
+	if endpoint != "" {
+		return "exporting to " + endpoint
+	}
+	return "disabled (set OTEL_EXPORTER_OTLP_ENDPOINT)"
+}
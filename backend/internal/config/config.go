@@ -2,21 +2,151 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	Port          string
 	YouTubeAPIKey string
-	Environment   string
+
+	// Twitch app credentials for the Helix API
+	TwitchClientID     string
+	TwitchClientSecret string
+	// TwitchEventSubSecret signs EventSub webhooks; one is generated when empty
+	TwitchEventSubSecret string
+
+	Environment string
+	DataDir     string
+
+	// FrontendURL is the public address of the frontend, used in links sent to users
+	FrontendURL string
+	// PublicURL is the public address of this backend, used for push callbacks.
+	// Push subscriptions (WebSub, EventSub) are disabled when it is empty.
+	PublicURL string
+
+	// LogLevel is the lowest level logged: debug, info, warn or error.
+	// Upstream response bodies are only logged at debug.
+	LogLevel string
+	// LogFormat is text or json
+	LogFormat string
+
+	// FollowRefreshInterval is how often followed channels are checked for live status
+	FollowRefreshInterval time.Duration
+
+	// ScheduleRefreshInterval is how often followed channels are checked for
+	// scheduled broadcasts. YouTube searches cost 100 quota units each.
+	ScheduleRefreshInterval time.Duration
+
+	// TopCacheTTL is how long live leaderboards are cached
+	TopCacheTTL time.Duration
+	// CategoryCacheTTL is how long category listings are cached
+	CategoryCacheTTL time.Duration
+
+	// KickProvider is "official" to use Kick's public API where it can, with
+	// the client credentials below, or "unofficial" to use only the site API
+	KickProvider     string
+	KickClientID     string
+	KickClientSecret string
+
+	// KickSearchEndpoints are the Kick search URLs to try, with {query} for the
+	// search query; the built-in ones are used when empty
+	KickSearchEndpoints []string
+	// KickEndpointCooldown is how long a failing Kick endpoint is first skipped
+	KickEndpointCooldown time.Duration
+
+	// ProviderMaxRetries is how many times an idempotent provider call is
+	// retried after a network error or a 429/5xx answer
+	ProviderMaxRetries int
+	// ProviderRetryBackoff is the delay before the first retry; it doubles on each retry
+	ProviderRetryBackoff time.Duration
+	// BreakerFailureThreshold is how many provider failures in a row open its
+	// circuit breaker, after which calls fail fast with provider_unavailable
+	BreakerFailureThreshold int
+	// BreakerOpenTimeout is how long an open breaker waits before probing the provider
+	BreakerOpenTimeout time.Duration
+
+	// HealthCheckTTL is how long dependency check results are reused by
+	// readiness probes
+	HealthCheckTTL time.Duration
+
+	// OTLPEndpoint is the OTLP/HTTP collector traces are exported to, e.g.
+	// http://localhost:4318; tracing is off when empty
+	OTLPEndpoint string
+	// TraceSampleRatio is the fraction of new traces that are recorded;
+	// traces started by the frontend follow its sampling decision
+	TraceSampleRatio float64
+
+	// SearchCursorSecret signs search pagination cursors; one is generated when empty
+	SearchCursorSecret string
+
+	// ViewerSampleInterval is how often viewer counts of live follows are recorded
+	ViewerSampleInterval time.Duration
+	// ViewerRawRetention is how long raw samples are kept before they are
+	// rolled up into hourly aggregates
+	ViewerRawRetention time.Duration
+	// ViewerHistoryRetention is how long hourly aggregates are kept
+	ViewerHistoryRetention time.Duration
+
+	// WebhookMaxAttempts is how many times a webhook delivery is attempted
+	WebhookMaxAttempts int
+	// WebhookRetryBackoff is the delay before the first retry; it doubles on each attempt
+	WebhookRetryBackoff time.Duration
+	// WebhookAllowPrivate lets webhooks target loopback and private
+	// addresses; only enable it when the API isn't reachable by untrusted users
+	WebhookAllowPrivate bool
+
+	// VAPIDSubject is the contact (mailto: or https: URL) push services can reach us at
+	VAPIDSubject string
+
+	// WebSubHubURL is the hub YouTube channel feeds are subscribed on
+	WebSubHubURL string
+	// WebSubLease is the lease requested for each hub subscription
+	WebSubLease time.Duration
 }
 
 // Load returns a new Config with values from environment variables
 func Load() *Config {
 	return &Config{
-		Port:          getEnv("PORT", "8080"),
-		YouTubeAPIKey: getEnv("YOUTUBE_API_KEY", ""),
-		Environment:   getEnv("ENVIRONMENT", "development"),
+		Port:                    getEnv("PORT", "8080"),
+		YouTubeAPIKey:           getEnv("YOUTUBE_API_KEY", ""),
+		TwitchClientID:          getEnv("TWITCH_CLIENT_ID", ""),
+		TwitchClientSecret:      getEnv("TWITCH_CLIENT_SECRET", ""),
+		TwitchEventSubSecret:    getEnv("TWITCH_EVENTSUB_SECRET", ""),
+		Environment:             getEnv("ENVIRONMENT", "development"),
+		DataDir:                 getEnv("DATA_DIR", "data"),
+		FrontendURL:             getEnv("FRONTEND_URL", "http://localhost:3000"),
+		PublicURL:               getEnv("PUBLIC_URL", ""),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		LogFormat:               getEnv("LOG_FORMAT", "text"),
+		FollowRefreshInterval:   getDurationEnv("FOLLOW_REFRESH_INTERVAL", 2*time.Minute),
+		ScheduleRefreshInterval: getDurationEnv("SCHEDULE_REFRESH_INTERVAL", time.Hour),
+		TopCacheTTL:             getDurationEnv("TOP_CACHE_TTL", time.Minute),
+		CategoryCacheTTL:        getDurationEnv("CATEGORY_CACHE_TTL", 10*time.Minute),
+		KickProvider:            getEnv("KICK_PROVIDER", "unofficial"),
+		KickClientID:            getEnv("KICK_CLIENT_ID", ""),
+		KickClientSecret:        getEnv("KICK_CLIENT_SECRET", ""),
+		KickSearchEndpoints:     getListEnv("KICK_SEARCH_ENDPOINTS"),
+		KickEndpointCooldown:    getDurationEnv("KICK_ENDPOINT_COOLDOWN", 5*time.Minute),
+		ProviderMaxRetries:      getIntEnv("PROVIDER_MAX_RETRIES", 2),
+		ProviderRetryBackoff:    getDurationEnv("PROVIDER_RETRY_BACKOFF", 250*time.Millisecond),
+		BreakerFailureThreshold: getIntEnv("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerOpenTimeout:      getDurationEnv("BREAKER_OPEN_TIMEOUT", 30*time.Second),
+		HealthCheckTTL:          getDurationEnv("HEALTH_CHECK_TTL", 30*time.Second),
+		OTLPEndpoint:            getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TraceSampleRatio:        getFloatEnv("TRACE_SAMPLE_RATIO", 1),
+		SearchCursorSecret:      getEnv("SEARCH_CURSOR_SECRET", ""),
+		ViewerSampleInterval:    getDurationEnv("VIEWER_SAMPLE_INTERVAL", time.Minute),
+		ViewerRawRetention:      getDurationEnv("VIEWER_RAW_RETENTION", 48*time.Hour),
+		ViewerHistoryRetention:  getDurationEnv("VIEWER_HISTORY_RETENTION", 90*24*time.Hour),
+		WebhookMaxAttempts:      getIntEnv("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookRetryBackoff:     getDurationEnv("WEBHOOK_RETRY_BACKOFF", 10*time.Second),
+		WebhookAllowPrivate:     getBoolEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		VAPIDSubject:            getEnv("VAPID_SUBJECT", "mailto:admin@localhost"),
+		WebSubHubURL:            getEnv("WEBSUB_HUB_URL", "https://pubsubhubbub.appspot.com/subscribe"),
+		WebSubLease:             getDurationEnv("WEBSUB_LEASE", 5*24*time.Hour),
 	}
 }
 
@@ -26,3 +156,49 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getListEnv(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
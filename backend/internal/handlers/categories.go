@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// CategoryHandler handles category browsing requests
+type CategoryHandler struct {
+	Categories *services.CategoryService
+}
+
+// NewCategoryHandler creates a new category handler
+func NewCategoryHandler(categories *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		Categories: categories,
+	}
+}
+
+// List handles GET /api/v1/categories
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	platform := r.URL.Query().Get("platform")
+	query := strings.TrimSpace(r.URL.Query().Get("query"))
+
+	switch platform {
+	case "", "all", "youtube", "kick", "twitch":
+	default:
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, twitch, or all")
+		return
+	}
+
+	categories, err := h.Categories.List(r.Context(), platform, query)
+	if err != nil {
+		h.sendError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, models.CategoriesResponse{
+		Categories: categories,
+		Count:      len(categories),
+	})
+}
+
+// Get handles GET /api/v1/categories/{id}
+func (h *CategoryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	category, err := h.Categories.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, category)
+}
+
+// Streams handles GET /api/v1/categories/{id}/streams
+func (h *CategoryHandler) Streams(w http.ResponseWriter, r *http.Request) {
+	language := strings.TrimSpace(r.URL.Query().Get("language"))
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+
+	category, streams, err := h.Categories.Streams(r.Context(), chi.URLParam(r, "id"), language)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if len(streams) > limit {
+		streams = streams[:limit]
+	}
+
+	h.sendJSON(w, http.StatusOK, models.CategoryStreamsResponse{
+		Category: *category,
+		Streams:  streams,
+		Count:    len(streams),
+	})
+}
+
+func (h *CategoryHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *CategoryHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
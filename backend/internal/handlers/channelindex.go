@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"multistream/backend/internal/services"
+)
+
+// ChannelIndexHandler handles administration of the local channel index
+type ChannelIndexHandler struct {
+	Channels *services.ChannelIndex
+}
+
+// NewChannelIndexHandler creates a new channel index handler
+func NewChannelIndexHandler(channels *services.ChannelIndex) *ChannelIndexHandler {
+	return &ChannelIndexHandler{
+		Channels: channels,
+	}
+}
+
+// Stats handles GET /api/v1/admin/channel-index
+func (h *ChannelIndexHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, h.Channels.Stats())
+}
+
+// Rebuild handles POST /api/v1/admin/channel-index/rebuild
+func (h *ChannelIndexHandler) Rebuild(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, h.Channels.Rebuild())
+}
+
+func (h *ChannelIndexHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
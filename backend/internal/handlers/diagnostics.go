@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// DiagnosticsHandler exposes what the backend has learned about upstream APIs
+type DiagnosticsHandler struct {
+	Kick *services.KickService
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler
+func NewDiagnosticsHandler(kick *services.KickService) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		Kick: kick,
+	}
+}
+
+// KickEndpoints handles GET /api/v1/diagnostics/kick/endpoints
+func (h *DiagnosticsHandler) KickEndpoints(w http.ResponseWriter, r *http.Request) {
+	endpoints := h.Kick.SearchEndpointHealth()
+	h.sendJSON(w, http.StatusOK, models.EndpointHealthResponse{
+		Endpoints: endpoints,
+		Count:     len(endpoints),
+	})
+}
+
+func (h *DiagnosticsHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
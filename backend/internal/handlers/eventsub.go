@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"multistream/backend/internal/services"
+)
+
+// EventSubHandler handles Twitch EventSub webhook callbacks
+type EventSubHandler struct {
+	EventSub *services.EventSubService
+}
+
+// NewEventSubHandler creates a new EventSub handler
+func NewEventSubHandler(eventsub *services.EventSubService) *EventSubHandler {
+	return &EventSubHandler{
+		EventSub: eventsub,
+	}
+}
+
+// Callback handles POST /api/v1/eventsub/twitch
+func (h *EventSubHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPushBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result := h.EventSub.Handle(r.Context(), r.Header, body)
+
+	if result.ContentType != "" {
+		w.Header().Set("Content-Type", result.ContentType)
+	}
+	w.WriteHeader(result.Status)
+	if result.Body != "" {
+		io.WriteString(w, result.Body)
+	}
+}
+
+// Subscriptions handles GET /api/v1/eventsub/subscriptions
+func (h *EventSubHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	subs := h.EventSub.Subscriptions()
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"subscriptions": subs,
+		"count":         len(subs),
+	})
+}
+
+func (h *EventSubHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
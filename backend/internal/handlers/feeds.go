@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// FeedHandler handles feed token and subscribable feed requests
+type FeedHandler struct {
+	Feeds     *services.FeedService
+	PublicURL string
+}
+
+// NewFeedHandler creates a new feed handler. Feed URLs are built from
+// publicURL, or from the request when it is empty.
+func NewFeedHandler(feeds *services.FeedService, publicURL string) *FeedHandler {
+	return &FeedHandler{
+		Feeds:     feeds,
+		PublicURL: publicURL,
+	}
+}
+
+// ListTokens handles GET /api/v1/feeds/tokens
+func (h *FeedHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens := h.Feeds.ListTokens()
+
+	h.sendJSON(w, http.StatusOK, models.FeedTokensResponse{
+		Tokens: tokens,
+		Count:  len(tokens),
+	})
+}
+
+// CreateToken handles POST /api/v1/feeds/tokens
+func (h *FeedHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var req models.FeedTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	token, err := h.Feeds.CreateToken(req)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The token and the URLs embedding it are only returned once
+	token.URLs = h.Feeds.FeedURLs(h.baseURL(r), token.Token)
+	h.sendJSON(w, http.StatusCreated, token)
+}
+
+// DeleteToken handles DELETE /api/v1/feeds/tokens/{id}
+func (h *FeedHandler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	if !h.Feeds.DeleteToken(chi.URLParam(r, "id")) {
+		h.sendError(w, http.StatusNotFound, "feed token not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Calendar handles GET /feeds/schedule.ics
+func (h *FeedHandler) Calendar(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="multistream.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.Feeds.Calendar(*token))
+}
+
+// LiveAtom handles GET /feeds/live.atom
+func (h *FeedHandler) LiveAtom(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := h.Feeds.LiveAtom(*token, h.baseURL(r)+r.URL.RequestURI())
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// LiveRSS handles GET /feeds/live.rss
+func (h *FeedHandler) LiveRSS(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := h.Feeds.LiveRSS(*token)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// authenticate resolves the feed token from the token query parameter, which
+// calendar clients can carry, or a bearer Authorization header
+func (h *FeedHandler) authenticate(w http.ResponseWriter, r *http.Request) (*models.FeedToken, bool) {
+	secret := r.URL.Query().Get("token")
+	if secret == "" {
+		secret = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	token, ok := h.Feeds.Authenticate(secret)
+	if !ok {
+		h.sendError(w, http.StatusUnauthorized, "invalid or missing feed token")
+		return nil, false
+	}
+	return token, true
+}
+
+// baseURL returns the address clients reach this API at
+func (h *FeedHandler) baseURL(r *http.Request) string {
+	if h.PublicURL != "" {
+		return h.PublicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+func (h *FeedHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *FeedHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
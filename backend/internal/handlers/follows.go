@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// FollowHandler handles followed channel requests
+type FollowHandler struct {
+	Follows *services.FollowService
+}
+
+// NewFollowHandler creates a new follow handler
+func NewFollowHandler(follows *services.FollowService) *FollowHandler {
+	return &FollowHandler{
+		Follows: follows,
+	}
+}
+
+// List handles GET /api/v1/follows
+func (h *FollowHandler) List(w http.ResponseWriter, r *http.Request) {
+	follows := h.Follows.List()
+
+	if list := r.URL.Query().Get("list"); list != "" {
+		filtered := make([]models.Follow, 0, len(follows))
+		for _, f := range follows {
+			if f.InList([]string{list}) {
+				filtered = append(filtered, f)
+			}
+		}
+		follows = filtered
+	}
+
+	h.sendJSON(w, http.StatusOK, models.FollowsResponse{
+		Follows: follows,
+		Count:   len(follows),
+	})
+}
+
+// Live handles GET /api/v1/follows/live
+func (h *FollowHandler) Live(w http.ResponseWriter, r *http.Request) {
+	follows := h.Follows.Live()
+
+	h.sendJSON(w, http.StatusOK, models.FollowsResponse{
+		Follows: follows,
+		Count:   len(follows),
+	})
+}
+
+// Add handles POST /api/v1/follows
+func (h *FollowHandler) Add(w http.ResponseWriter, r *http.Request) {
+	var req models.FollowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	switch req.Platform {
+	case "youtube", "kick", "twitch":
+	default:
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, or twitch")
+		return
+	}
+
+	if req.Channel == "" {
+		h.sendError(w, http.StatusBadRequest, "channel is required")
+		return
+	}
+
+	follow, err := h.Follows.Add(r.Context(), req.Platform, req.Channel, req.Lists)
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, services.ErrProviderUnavailable):
+		h.sendError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	case err != nil:
+		h.sendError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, follow)
+}
+
+// Update handles PATCH /api/v1/follows/{platform}/{channel}
+func (h *FollowHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req models.FollowUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	follow, ok := h.Follows.SetLists(chi.URLParam(r, "platform"), chi.URLParam(r, "channel"), req.Lists)
+	if !ok {
+		h.sendError(w, http.StatusNotFound, "follow not found")
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, follow)
+}
+
+// Remove handles DELETE /api/v1/follows/{platform}/{channel}
+func (h *FollowHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	platform := chi.URLParam(r, "platform")
+	channel := chi.URLParam(r, "channel")
+
+	if !h.Follows.Remove(platform, channel) {
+		h.sendError(w, http.StatusNotFound, "follow not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *FollowHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *FollowHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
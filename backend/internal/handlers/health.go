@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"multistream/backend/internal/services"
+)
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	Health *services.HealthService
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(health *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		Health: health,
+	}
+}
+
+// Live handles GET /api/health/live. It only shows the process is serving,
+// so an upstream outage never gets the backend restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, map[string]string{"status": services.HealthHealthy})
+}
+
+// Ready handles GET /api/health/ready. Degraded still counts as ready, since
+// the backend serves what the healthy providers offer; unhealthy answers 503.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.Health.Ready()
+
+	status := http.StatusOK
+	if report.Status == services.HealthUnhealthy {
+		status = http.StatusServiceUnavailable
+	}
+	h.sendJSON(w, status, report)
+}
+
+func (h *HealthHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
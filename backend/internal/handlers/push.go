@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// PushHandler handles Web Push subscription requests
+type PushHandler struct {
+	Push *services.PushService
+}
+
+// NewPushHandler creates a new push handler
+func NewPushHandler(push *services.PushService) *PushHandler {
+	return &PushHandler{
+		Push: push,
+	}
+}
+
+// PublicKey handles GET /api/v1/push/vapid-public-key
+func (h *PushHandler) PublicKey(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, models.PushKeyResponse{
+		PublicKey: h.Push.PublicKey(),
+	})
+}
+
+// Subscribe handles POST /api/v1/push/subscriptions
+func (h *PushHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var req models.PushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	sub, err := h.Push.Subscribe(req)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusCreated, sub)
+}
+
+// Unsubscribe handles DELETE /api/v1/push/subscriptions/{id}
+func (h *PushHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if !h.Push.Unsubscribe(chi.URLParam(r, "id")) {
+		h.sendError(w, http.StatusNotFound, "subscription not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PushHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *PushHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// ScheduleHandler handles upcoming broadcast requests
+type ScheduleHandler struct {
+	Schedule *services.ScheduleService
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(schedule *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{
+		Schedule: schedule,
+	}
+}
+
+// List handles GET /api/v1/schedule
+func (h *ScheduleHandler) List(w http.ResponseWriter, r *http.Request) {
+	var lists []string
+	if list := r.URL.Query().Get("list"); list != "" {
+		lists = []string{list}
+	}
+
+	streams := h.Schedule.Upcoming(lists)
+
+	h.sendJSON(w, http.StatusOK, models.ScheduleResponse{
+		Streams: streams,
+		Count:   len(streams),
+	})
+}
+
+func (h *ScheduleHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
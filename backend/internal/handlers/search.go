@@ -2,25 +2,41 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"multistream/backend/internal/models"
 	"multistream/backend/internal/services"
 )
 
+// SearchParameters documents the search endpoint's query parameters
+var SearchParameters = map[string]string{
+	"platform":       "youtube, kick, twitch, or all (default: all; Twitch only when configured)",
+	"query":          "search terms (required)",
+	"limit":          "results to return, 1-50 (default 20)",
+	"state":          "live or upcoming; pushed down to YouTube, Twitch live_only",
+	"live":           "true is shorthand for state=live",
+	"min_viewers":    "minimum current viewers; streams that aren't live have none",
+	"max_viewers":    "maximum current viewers",
+	"language":       "ISO 639-1 code; YouTube relevanceLanguage, then filtered (Kick search has no language)",
+	"category":       "game or category name, matched across platform aliases; added to the YouTube query",
+	"verified":       "true for verified Kick channels and Twitch partners (YouTube does not report verification)",
+	"exclude_mature": "true to drop mature streams; YouTube safeSearch=strict",
+	"sort":           "relevance (default; ranked across platforms by match, live status and viewers), viewers, recent, or uptime",
+	"cursor":         "nextCursor of the previous page; other parameters must match that search",
+}
+
 // SearchHandler handles stream search requests
 type SearchHandler struct {
-	YouTube *services.YouTubeService
-	Kick    *services.KickService
+	Searches *services.SearchService
 }
 
 // NewSearchHandler creates a new search handler
-func NewSearchHandler(youtube *services.YouTubeService, kick *services.KickService) *SearchHandler {
-	return &SearchHandler{
-		YouTube: youtube,
-		Kick:    kick,
-	}
+func NewSearchHandler(search *services.SearchService) *SearchHandler {
+	return &SearchHandler{Searches: search}
 }
 
 // Search handles GET /api/v1/search
@@ -41,51 +57,102 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var streamers []models.Streamer
-	var err error
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	switch platform {
-	case "youtube":
-		streamers, err = h.YouTube.SearchLiveStreams(query, limit)
-	case "kick":
-		streamers, err = h.Kick.SearchLiveStreams(query, limit)
-	case "", "all":
-		// Search all platforms
-		streamers = make([]models.Streamer, 0)
-
-		ytStreamers, ytErr := h.YouTube.SearchLiveStreams(query, limit/2)
-		if ytErr == nil {
-			streamers = append(streamers, ytStreamers...)
-		}
-
-		kickStreamers, kickErr := h.Kick.SearchLiveStreams(query, limit/2)
-		if kickErr == nil {
-			streamers = append(streamers, kickStreamers...)
-		}
-
-		// If both failed, return error
-		if ytErr != nil && kickErr != nil {
-			err = ytErr
-		}
+	case "", "all", "youtube", "kick", "twitch":
 	default:
-		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, or all")
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, twitch, or all")
 		return
 	}
 
+	result, err := h.Searches.Search(r.Context(), services.SearchRequest{
+		Platform: platform,
+		Query:    query,
+		Limit:    limit,
+		Filter:   filter,
+		Cursor:   r.URL.Query().Get("cursor"),
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrProviderUnavailable) {
+		h.sendError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
 	if err != nil {
 		h.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	response := models.SearchResponse{
-		Streamers: streamers,
-		Platform:  platform,
-		Query:     query,
+		Streamers:  result.Streamers,
+		Platform:   platform,
+		Query:      query,
+		NextCursor: result.NextCursor,
+		Offline:    result.Offline,
 	}
 
 	h.sendJSON(w, http.StatusOK, response)
 }
 
+// parseSearchFilter reads the filter and sort query parameters
+func parseSearchFilter(r *http.Request) (services.SearchFilter, error) {
+	q := r.URL.Query()
+	filter := services.SearchFilter{
+		State:    q.Get("state"),
+		Language: strings.TrimSpace(q.Get("language")),
+		Category: strings.TrimSpace(q.Get("category")),
+		Sort:     q.Get("sort"),
+	}
+
+	var err error
+	if filter.MinViewers, err = intParam(q.Get("min_viewers")); err != nil {
+		return filter, fmt.Errorf("invalid min_viewers: %w", err)
+	}
+	if filter.MaxViewers, err = intParam(q.Get("max_viewers")); err != nil {
+		return filter, fmt.Errorf("invalid max_viewers: %w", err)
+	}
+	if filter.VerifiedOnly, err = boolParam(q.Get("verified")); err != nil {
+		return filter, fmt.Errorf("invalid verified: %w", err)
+	}
+	if filter.ExcludeMature, err = boolParam(q.Get("exclude_mature")); err != nil {
+		return filter, fmt.Errorf("invalid exclude_mature: %w", err)
+	}
+
+	liveOnly, err := boolParam(q.Get("live"))
+	if err != nil {
+		return filter, fmt.Errorf("invalid live: %w", err)
+	}
+	if liveOnly {
+		if filter.State == "upcoming" {
+			return filter, fmt.Errorf("live=true conflicts with state=upcoming")
+		}
+		filter.State = "live"
+	}
+
+	return filter, filter.Validate()
+}
+
+func intParam(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func boolParam(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
 func (h *SearchHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
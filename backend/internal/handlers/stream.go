@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -14,13 +15,15 @@ import (
 type StreamHandler struct {
 	YouTube *services.YouTubeService
 	Kick    *services.KickService
+	Twitch  *services.TwitchService
 }
 
 // NewStreamHandler creates a new stream handler
-func NewStreamHandler(youtube *services.YouTubeService, kick *services.KickService) *StreamHandler {
+func NewStreamHandler(youtube *services.YouTubeService, kick *services.KickService, twitch *services.TwitchService) *StreamHandler {
 	return &StreamHandler{
 		YouTube: youtube,
 		Kick:    kick,
+		Twitch:  twitch,
 	}
 }
 
@@ -39,14 +42,20 @@ func (h *StreamHandler) GetStream(w http.ResponseWriter, r *http.Request) {
 
 	switch platform {
 	case "youtube":
-		streamer, err = h.YouTube.GetStreamInfo(streamID)
+		streamer, err = h.YouTube.GetStreamInfo(r.Context(), streamID)
 	case "kick":
-		streamer, err = h.Kick.GetChannelInfo(streamID)
+		streamer, err = h.Kick.GetChannelInfo(r.Context(), streamID)
+	case "twitch":
+		streamer, err = h.Twitch.GetChannelInfo(r.Context(), streamID)
 	default:
-		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube or kick")
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, or twitch")
 		return
 	}
 
+	if errors.Is(err, services.ErrProviderUnavailable) {
+		h.sendError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
 	if err != nil {
 		h.sendError(w, http.StatusNotFound, err.Error())
 		return
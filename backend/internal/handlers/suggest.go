@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// SuggestHandler handles search suggestion requests
+type SuggestHandler struct {
+	Channels *services.ChannelIndex
+}
+
+// NewSuggestHandler creates a new suggestion handler
+func NewSuggestHandler(channels *services.ChannelIndex) *SuggestHandler {
+	return &SuggestHandler{
+		Channels: channels,
+	}
+}
+
+// Suggest handles GET /api/v1/suggest. It only reads the local channel
+// index, so it is cheap enough to call on every keystroke.
+func (h *SuggestHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	platform := query.Get("platform")
+
+	if q == "" {
+		h.sendError(w, http.StatusBadRequest, "q parameter is required")
+		return
+	}
+
+	switch platform {
+	case "", "all", "youtube", "kick", "twitch":
+	default:
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, twitch, or all")
+		return
+	}
+
+	limit := 8
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 20 {
+		limit = l
+	}
+
+	suggestions := h.Channels.Suggest(q, platform, limit)
+	h.sendJSON(w, http.StatusOK, models.SuggestResponse{
+		Query:       q,
+		Suggestions: suggestions,
+		Count:       len(suggestions),
+	})
+}
+
+func (h *SuggestHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *SuggestHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// TopHandler handles live leaderboard requests
+type TopHandler struct {
+	Top *services.TopService
+}
+
+// NewTopHandler creates a new leaderboard handler
+func NewTopHandler(top *services.TopService) *TopHandler {
+	return &TopHandler{
+		Top: top,
+	}
+}
+
+// List handles GET /api/v1/top
+func (h *TopHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := services.TopFilter{
+		Platform: query.Get("platform"),
+		Category: strings.TrimSpace(query.Get("category")),
+		Language: strings.TrimSpace(query.Get("language")),
+	}
+
+	switch filter.Platform {
+	case "", "all", "youtube", "kick", "twitch":
+	default:
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, twitch, or all")
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(query.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	streams, fetchedAt, err := h.Top.Top(r.Context(), filter)
+	if err != nil {
+		h.sendError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	start := min((page-1)*limit, len(streams))
+	end := min(start+limit, len(streams))
+
+	h.sendJSON(w, http.StatusOK, models.TopResponse{
+		Streams:   streams[start:end],
+		Page:      page,
+		Limit:     limit,
+		Total:     len(streams),
+		HasMore:   end < len(streams),
+		FetchedAt: fetchedAt,
+	})
+}
+
+func (h *TopHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *TopHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
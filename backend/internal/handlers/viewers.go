@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// defaultHistoryPoints is how many points a history query without a step aims for
+const defaultHistoryPoints = 200
+
+// ViewerHandler handles viewer count history requests
+type ViewerHandler struct {
+	Viewers *services.ViewerRecorder
+}
+
+// NewViewerHandler creates a new viewer handler
+func NewViewerHandler(viewers *services.ViewerRecorder) *ViewerHandler {
+	return &ViewerHandler{
+		Viewers: viewers,
+	}
+}
+
+// History handles GET /api/v1/stream/{platform}/{id}/history
+func (h *ViewerHandler) History(w http.ResponseWriter, r *http.Request) {
+	platform := chi.URLParam(r, "platform")
+	streamID := chi.URLParam(r, "id")
+
+	switch platform {
+	case "youtube", "kick", "twitch":
+	default:
+		h.sendError(w, http.StatusBadRequest, "invalid platform: must be youtube, kick, or twitch")
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := r.URL.Query().Get("to"); value != "" {
+		t, err := parseHistoryTime(value)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid to: use RFC 3339 or unix seconds")
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if value := r.URL.Query().Get("from"); value != "" {
+		t, err := parseHistoryTime(value)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid from: use RFC 3339 or unix seconds")
+			return
+		}
+		from = t
+	}
+
+	step := (to.Sub(from) / defaultHistoryPoints).Truncate(time.Minute)
+	if step < time.Minute {
+		step = time.Minute
+	}
+	if value := r.URL.Query().Get("step"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid step: use a duration such as 5m or 1h")
+			return
+		}
+		step = d
+	}
+
+	points, err := h.Viewers.History(platform, streamID, from, to, step)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, models.ViewerHistoryResponse{
+		Platform: platform,
+		ID:       streamID,
+		From:     from,
+		To:       to,
+		Step:     step.String(),
+		Points:   points,
+	})
+}
+
+// parseHistoryTime accepts RFC 3339 timestamps and unix seconds
+func parseHistoryTime(value string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t.UTC(), err
+}
+
+func (h *ViewerHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *ViewerHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
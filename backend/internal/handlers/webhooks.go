@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/services"
+)
+
+// WebhookHandler handles notification webhook requests
+type WebhookHandler struct {
+	Webhooks *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhooks *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		Webhooks: webhooks,
+	}
+}
+
+// List handles GET /api/v1/notifications/webhooks
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	webhooks := h.Webhooks.List()
+
+	h.sendJSON(w, http.StatusOK, models.WebhooksResponse{
+		Webhooks: webhooks,
+		Count:    len(webhooks),
+	})
+}
+
+// Create handles POST /api/v1/notifications/webhooks
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	webhook, err := h.Webhooks.Create(req)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The secret is only returned once, when the webhook is created
+	h.sendJSON(w, http.StatusCreated, webhook)
+}
+
+// Get handles GET /api/v1/notifications/webhooks/{id}
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.Webhooks.Get(chi.URLParam(r, "id"))
+	if !ok {
+		h.sendError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, webhook)
+}
+
+// Delete handles DELETE /api/v1/notifications/webhooks/{id}
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.Webhooks.Delete(chi.URLParam(r, "id")) {
+		h.sendError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Test handles POST /api/v1/notifications/webhooks/{id}/test
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.Webhooks.Test(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusAccepted, delivery)
+}
+
+// Deliveries handles GET /api/v1/notifications/webhooks/{id}/deliveries
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, ok := h.Webhooks.Get(id); !ok {
+		h.sendError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	deliveries := h.Webhooks.Deliveries(id)
+
+	h.sendJSON(w, http.StatusOK, models.DeliveriesResponse{
+		Deliveries: deliveries,
+		Count:      len(deliveries),
+	})
+}
+
+// Replay handles POST /api/v1/notifications/webhooks/{id}/deliveries/{deliveryID}/replay
+func (h *WebhookHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.Webhooks.Replay(chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.sendJSON(w, http.StatusAccepted, delivery)
+}
+
+func (h *WebhookHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *WebhookHandler) sendError(w http.ResponseWriter, status int, message string) {
+	h.sendJSON(w, status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+		Code:    status,
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/services"
+)
+
+var websubLog = logging.Component("websub")
+
+// maxPushBodySize limits the size of feeds pushed by the hub
+const maxPushBodySize = 1 << 20
+
+// WebSubHandler handles WebSub hub callbacks for YouTube channels
+type WebSubHandler struct {
+	WebSub *services.WebSubService
+}
+
+// NewWebSubHandler creates a new WebSub handler
+func NewWebSubHandler(websub *services.WebSubService) *WebSubHandler {
+	return &WebSubHandler{
+		WebSub: websub,
+	}
+}
+
+// Verify handles GET /api/v1/websub/youtube/{channel}
+func (h *WebSubHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.WebSub.Verify(chi.URLParam(r, "channel"), r.URL.Query())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, challenge)
+}
+
+// Notify handles POST /api/v1/websub/youtube/{channel}
+func (h *WebSubHandler) Notify(w http.ResponseWriter, r *http.Request) {
+	channel := chi.URLParam(r, "channel")
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPushBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// The hub must always get a 2xx, even for content we reject
+	if err := h.WebSub.Notify(r.Context(), channel, r.Header.Get("X-Hub-Signature"), body); err != nil {
+		websubLog.WarnContext(r.Context(), "Ignored push", "error", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Subscriptions handles GET /api/v1/websub/subscriptions
+func (h *WebSubHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	subs := h.WebSub.Subscriptions()
+
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"subscriptions": subs,
+		"count":         len(subs),
+	})
+}
+
+func (h *WebSubHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,222 @@
+// Package logging sets up structured logging with log/slog. Records carry
+// the request ID of the request that caused them and never contain
+// credentials.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxBodyLength is how much of a response body is logged at debug level
+const maxBodyLength = 500
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = map[string]bool{
+	"key":           true,
+	"api_key":       true,
+	"apikey":        true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+	"secret":        true,
+	"password":      true,
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"hub.secret":    true,
+	"auth":          true,
+	"p256dh":        true,
+}
+
+// secretPatterns find credentials inside logged text, such as URLs in
+// error messages or upstream response bodies
+var secretPatterns = []*regexp.Regexp{
+	// Query strings and form bodies
+	regexp.MustCompile(`(?i)((?:^|[?&\s"'])(?:key|api_key|access_token|refresh_token|client_secret|token|hub\.secret)=)[^&\s"']+`),
+	// JSON fields
+	regexp.MustCompile(`(?i)("(?:access_token|refresh_token|client_secret|api_key|token|secret|password)"\s*:\s*")[^"]*`),
+	// Authorization and cookie headers
+	regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`),
+	regexp.MustCompile(`(?i)((?:set-)?cookie:\s*)[^\r\n"]+`),
+	// Discord and Slack webhook URLs, whose path is the credential
+	regexp.MustCompile(`(?i)(discord(?:app)?\.com/api/webhooks/\d+/)[^\s"'?]+`),
+	regexp.MustCompile(`(?i)(hooks\.slack\.com/services/)[^\s"'?]+`),
+}
+
+// Setup makes a logger writing to w the default, for slog and the log
+// package. Level is debug, info, warn or error; format is text or json.
+func Setup(w io.Writer, level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text", "":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q: must be text or json", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// Component returns a logger tagging records with the part of the backend
+// that logged them. It follows slog.Default, so it can be created before
+// Setup runs.
+func Component(name string) *slog.Logger {
+	return slog.New(lazyHandler{}).With("component", name)
+}
+
+// Body shortens a response body for a debug record
+func Body(body []byte) string {
+	if len(body) <= maxBodyLength {
+		return string(body)
+	}
+	return string(body[:maxBodyLength]) + "..."
+}
+
+// Redact removes credentials from text
+func Redact(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+redacted)
+	}
+	return s
+}
+
+// redactAttr hides the values of sensitive attributes and scrubs
+// credentials from messages, strings and errors
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+// contextHandler adds the request and trace IDs found in the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// lazyHandler hands records to whatever handler is the default when they
+// are logged, applying the attributes and groups added to it on the way
+type lazyHandler struct {
+	wrap func(slog.Handler) slog.Handler
+}
+
+func (h lazyHandler) handler() slog.Handler {
+	handler := slog.Default().Handler()
+	if h.wrap != nil {
+		handler = h.wrap(handler)
+	}
+	return handler
+}
+
+func (h lazyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (h lazyHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+func (h lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.chain(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h lazyHandler) WithGroup(name string) slog.Handler {
+	return h.chain(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h lazyHandler) chain(step func(slog.Handler) slog.Handler) lazyHandler {
+	wrap := h.wrap
+	return lazyHandler{wrap: func(next slog.Handler) slog.Handler {
+		if wrap != nil {
+			next = wrap(next)
+		}
+		return step(next)
+	}}
+}
+
+// Middleware logs every request once it has been served. It expects
+// chi's RequestID middleware to run first and echoes the ID back in the
+// X-Request-Id header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(middleware.RequestIDHeader, id)
+		}
+
+		started := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := r.URL.Path
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.Default().LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Duration("duration", time.Since(started)),
+			slog.String("remote", r.RemoteAddr),
+		)
+	})
+}
@@ -0,0 +1,131 @@
+// Package metrics holds the Prometheus collectors of the backend and the
+// handler and middleware that expose and feed them
+package metrics
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "multistream"
+
+var (
+	// HTTPRequests counts served requests by route pattern and status
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration is how long requests take to serve by route pattern
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to serve HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// UpstreamRequests counts attempts at provider calls, retries included
+	UpstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Requests to providers, by provider, endpoint and status (\"error\" when no answer came).",
+	}, []string{"provider", "endpoint", "status"})
+
+	// UpstreamDuration is how long provider calls take per attempt
+	UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time per request to providers, by provider and endpoint.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 15},
+	}, []string{"provider", "endpoint"})
+
+	// UpstreamErrors counts failed provider calls by reason: "network",
+	// "status" for 429 and 5xx answers, or "provider_unavailable" when a
+	// circuit breaker refused the call
+	UpstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Failed requests to providers, by provider, endpoint and reason.",
+	}, []string{"provider", "endpoint", "reason"})
+
+	// UpstreamRateLimited counts 429 answers from providers
+	UpstreamRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_rate_limited_total",
+		Help:      "Requests providers rejected with 429 Too Many Requests.",
+	}, []string{"provider"})
+
+	// CacheLookups counts cache hits and misses; the hit ratio is
+	// hits / (hits + misses)
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups, by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	// YouTubeQuota counts YouTube Data API quota units spent
+	YouTubeQuota = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "youtube_quota_units_total",
+		Help:      "YouTube Data API quota units consumed, by endpoint.",
+	}, []string{"endpoint"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count and duration of every request by the route
+// pattern that served it, so path parameters don't multiply the series
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// CacheLookup records a lookup in a cache
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// Endpoint names a provider endpoint by host and path. Segments naming a
+// channel or a language (after "channels" or "livestreams") and numeric
+// ones are collapsed, so the label stays bounded.
+func Endpoint(u *url.URL) string {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := range segments {
+		_, err := strconv.Atoi(segments[i])
+		if err == nil || (i > 0 && (segments[i-1] == "channels" || segments[i-1] == "livestreams")) {
+			segments[i] = "{id}"
+		}
+	}
+	return u.Host + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,33 @@
+package models
+
+// Category is a game or topic, merged across platforms under a shared ID
+// such as "valorant"
+type Category struct {
+	ID        string                      `json:"id"`
+	Name      string                      `json:"name"`
+	Image     string                      `json:"image,omitempty"`
+	Viewers   int                         `json:"viewers,omitempty"`
+	Platforms map[string]PlatformCategory `json:"platforms"`
+}
+
+// PlatformCategory is how a category is known on one platform. ID is empty
+// when the platform has no such category and streams are found by searching
+// for Name, as with games on YouTube.
+type PlatformCategory struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	Slug string `json:"slug,omitempty"`
+}
+
+// CategoriesResponse is the response for listing categories
+type CategoriesResponse struct {
+	Categories []Category `json:"categories"`
+	Count      int        `json:"count"`
+}
+
+// CategoryStreamsResponse is the response for the live streams of a category
+type CategoryStreamsResponse struct {
+	Category Category   `json:"category"`
+	Streams  []Streamer `json:"streams"`
+	Count    int        `json:"count"`
+}
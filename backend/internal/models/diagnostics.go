@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// EndpointHealth is what has been learned about one upstream endpoint
+type EndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	// Score moves toward 1 with each success and toward 0 with each failure
+	Score               float64    `json:"score"`
+	Successes           int        `json:"successes"`
+	Failures            int        `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastSuccess         *time.Time `json:"lastSuccess,omitempty"`
+	LastFailure         *time.Time `json:"lastFailure,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	// CooldownUntil is when a failing endpoint will be tried again
+	CooldownUntil *time.Time `json:"cooldownUntil,omitempty"`
+}
+
+// EndpointHealthResponse is the response for the endpoint diagnostics API
+type EndpointHealthResponse struct {
+	Endpoints []EndpointHealth `json:"endpoints"`
+	Count     int              `json:"count"`
+}
+
+// BreakerState is the state of a provider's circuit breaker
+type BreakerState struct {
+	Provider string `json:"provider"`
+	// State is "closed" while calls go through, "open" while they are
+	// refused and "half-open" while a probe decides which it will be
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Opens               int        `json:"opens"`
+	LastFailure         *time.Time `json:"lastFailure,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	// RetryAt is when an open breaker will let a probe through
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+}
+
+// DependencyHealth is the result of checking one dependency
+type DependencyHealth struct {
+	Name string `json:"name"`
+	// Status is "healthy", "degraded" or "unhealthy"
+	Status string `json:"status"`
+	// Critical dependencies make the whole service unhealthy when they are
+	Critical  bool      `json:"critical"`
+	Message   string    `json:"message,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthResponse is the response for the readiness API
+type HealthResponse struct {
+	Status   string             `json:"status"`
+	Checks   []DependencyHealth `json:"checks"`
+	Breakers []BreakerState     `json:"breakers"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Stream event types emitted when a followed channel changes
+const (
+	EventWentLive     = "went_live"
+	EventWentOffline  = "went_offline"
+	EventTitleChanged = "title_changed"
+)
+
+// EventTypes lists every stream event type
+var EventTypes = []string{EventWentLive, EventWentOffline, EventTitleChanged}
+
+// StreamEvent describes a change in the status of a followed channel
+type StreamEvent struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	FollowID      string    `json:"followId"`
+	Platform      string    `json:"platform"`
+	ChannelID     string    `json:"channelId"`
+	Lists         []string  `json:"lists,omitempty"`
+	Streamer      Streamer  `json:"streamer"`
+	PreviousTitle string    `json:"previousTitle,omitempty"`
+	OccurredAt    time.Time `json:"occurredAt"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// FeedToken grants read access to the subscribable feeds (calendar, Atom, RSS)
+// without any other credentials, so calendar and feed readers can poll them.
+// Lists restricts the feeds to follows in those follow lists.
+type FeedToken struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Token     string            `json:"token,omitempty"`
+	Lists     []string          `json:"lists,omitempty"`
+	URLs      map[string]string `json:"urls,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// FeedTokenRequest is the request body for creating a feed token
+type FeedTokenRequest struct {
+	Name  string   `json:"name"`
+	Lists []string `json:"lists,omitempty"`
+}
+
+// FeedTokensResponse is the response for listing feed tokens
+type FeedTokensResponse struct {
+	Tokens []FeedToken `json:"tokens"`
+	Count  int         `json:"count"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Follow is a followed channel on any platform, identified by the channel
+// rather than by an individual video or broadcast
+type Follow struct {
+	ID          string     `json:"id"`
+	Platform    string     `json:"platform"`
+	ChannelID   string     `json:"channelId"`
+	DisplayName string     `json:"displayName"`
+	Lists       []string   `json:"lists,omitempty"`
+	AddedAt     time.Time  `json:"addedAt"`
+	Status      *Streamer  `json:"status,omitempty"`
+	LiveSince   *time.Time `json:"liveSince,omitempty"`
+	LastChecked *time.Time `json:"lastChecked,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+
+	// StatusChangedAt is when the channel last went live or offline or
+	// changed its stream title
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty"`
+}
+
+// IsLive reports whether the last known status of the follow is live
+func (f Follow) IsLive() bool {
+	return f.Status != nil && f.Status.IsLive
+}
+
+// InList reports whether the follow belongs to any of the given lists.
+// An empty filter matches every follow.
+func (f Follow) InList(lists []string) bool {
+	if len(lists) == 0 {
+		return true
+	}
+	for _, list := range lists {
+		if slices.Contains(f.Lists, list) {
+			return true
+		}
+	}
+	return false
+}
+
+// FollowRequest is the request body for following a channel
+type FollowRequest struct {
+	Platform string   `json:"platform"`
+	Channel  string   `json:"channel"`
+	Lists    []string `json:"lists,omitempty"`
+}
+
+// FollowUpdateRequest is the request body for updating a follow
+type FollowUpdateRequest struct {
+	Lists []string `json:"lists"`
+}
+
+// FollowsResponse is the response for the follows API
+type FollowsResponse struct {
+	Follows []Follow `json:"follows"`
+	Count   int      `json:"count"`
+}
+
+// FollowID builds the identifier of a follow from its platform and channel
+func FollowID(platform, channelID string) string {
+	return platform + ":" + channelID
+}
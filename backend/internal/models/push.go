@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// PushSubscription is a browser Web Push subscription
+type PushSubscription struct {
+	ID        string    `json:"id"`
+	Endpoint  string    `json:"endpoint"`
+	Keys      PushKeys  `json:"keys"`
+	Events    []string  `json:"events"`
+	Lists     []string  `json:"lists,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PushKeys are the client keys from PushSubscription.toJSON()
+type PushKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// PushSubscriptionRequest is the request body for registering a push subscription.
+// Endpoint and Keys match the browser's PushSubscription.toJSON() output.
+type PushSubscriptionRequest struct {
+	Endpoint string   `json:"endpoint"`
+	Keys     PushKeys `json:"keys"`
+	Events   []string `json:"events,omitempty"`
+	Lists    []string `json:"lists,omitempty"`
+}
+
+// PushKeyResponse is the response for the VAPID public key API
+type PushKeyResponse struct {
+	PublicKey string `json:"publicKey"`
+}
+
+// PushNotification is the payload shown by the service worker
+type PushNotification struct {
+	Title string      `json:"title"`
+	Body  string      `json:"body"`
+	Icon  string      `json:"icon,omitempty"`
+	URL   string      `json:"url"`
+	Tag   string      `json:"tag"`
+	Event StreamEvent `json:"event"`
+}
@@ -1,17 +1,48 @@
 package models
 
+import "time"
+
+// Stream states
+const (
+	StateScheduled = "scheduled"
+	StateLive      = "live"
+	StateEnded     = "ended"
+	StateVOD       = "vod"
+	StateOffline   = "offline"
+)
+
+// LiveState returns the state of a channel that is either live or offline
+func LiveState(isLive bool) string {
+	if isLive {
+		return StateLive
+	}
+	return StateOffline
+}
+
 // Streamer represents a live streamer from any platform
 type Streamer struct {
-	ID          string `json:"id"`
-	Platform    string `json:"platform"`
-	Username    string `json:"username"`
-	DisplayName string `json:"displayName"`
-	Thumbnail   string `json:"thumbnail"`
-	Title       string `json:"title"`
-	ViewerCount int    `json:"viewerCount"`
-	IsLive      bool   `json:"isLive"`
-	EmbedURL    string `json:"embedUrl,omitempty"`
-	ChatURL     string `json:"chatUrl,omitempty"`
+	ID                 string     `json:"id"`
+	Platform           string     `json:"platform"`
+	Username           string     `json:"username"`
+	DisplayName        string     `json:"displayName"`
+	Thumbnail          string     `json:"thumbnail"`
+	Title              string     `json:"title"`
+	ViewerCount        int        `json:"viewerCount"`
+	Language           string     `json:"language,omitempty"`
+	Category           string     `json:"category,omitempty"`
+	Verified           bool       `json:"verified,omitempty"`
+	Mature             bool       `json:"mature,omitempty"`
+	IsLive             bool       `json:"isLive"`
+	State              string     `json:"state"`
+	ScheduledStartTime *time.Time `json:"scheduledStartTime,omitempty"`
+	ScheduledEndTime   *time.Time `json:"scheduledEndTime,omitempty"`
+	ActualStartTime    *time.Time `json:"actualStartTime,omitempty"`
+	EmbedURL           string     `json:"embedUrl,omitempty"`
+	ChatURL            string     `json:"chatUrl,omitempty"`
+
+	// BroadcastID identifies a single scheduled broadcast on platforms where
+	// ID names the channel rather than the stream
+	BroadcastID string `json:"broadcastId,omitempty"`
 }
 
 // SearchResponse is the response for search API
@@ -19,6 +50,27 @@ type SearchResponse struct {
 	Streamers []Streamer `json:"streamers"`
 	Platform  string     `json:"platform"`
 	Query     string     `json:"query"`
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Offline lists the platforms that failed and were answered from the
+	// local channel index, with live status as last seen
+	Offline []string `json:"offline,omitempty"`
+}
+
+// TopResponse is the response for the live leaderboard API
+type TopResponse struct {
+	Streams   []Streamer `json:"streams"`
+	Page      int        `json:"page"`
+	Limit     int        `json:"limit"`
+	Total     int        `json:"total"`
+	HasMore   bool       `json:"hasMore"`
+	FetchedAt time.Time  `json:"fetchedAt"`
+}
+
+// ScheduleResponse is the response for the schedule API
+type ScheduleResponse struct {
+	Streams []Streamer `json:"streams"`
+	Count   int        `json:"count"`
 }
 
 // StreamResponse is the response for stream info API
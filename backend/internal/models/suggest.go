@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Suggestion is a known channel offered while typing a search
+type Suggestion struct {
+	Platform    string    `json:"platform"`
+	ChannelID   string    `json:"channelId"`
+	DisplayName string    `json:"displayName"`
+	Thumbnail   string    `json:"thumbnail,omitempty"`
+	IsLive      bool      `json:"isLive"`
+	ViewerCount int       `json:"viewerCount"`
+	Followed    bool      `json:"followed,omitempty"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// SuggestResponse is the response for the suggest API
+type SuggestResponse struct {
+	Query       string       `json:"query"`
+	Suggestions []Suggestion `json:"suggestions"`
+	Count       int          `json:"count"`
+}
+
+// ChannelIndexStats describes the local channel index
+type ChannelIndexStats struct {
+	Channels  int            `json:"channels"`
+	Platforms map[string]int `json:"platforms"`
+	// Terms is the number of distinct indexed words, Postings the number of
+	// word-channel pairs
+	Terms     int       `json:"terms"`
+	Postings  int       `json:"postings"`
+	FileBytes int64     `json:"fileBytes"`
+	RebuiltAt time.Time `json:"rebuiltAt"`
+}
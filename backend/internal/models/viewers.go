@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ViewerPoint is one downsampled step of a viewer count series
+type ViewerPoint struct {
+	Time    time.Time `json:"time"`
+	Avg     int       `json:"avg"`
+	Min     int       `json:"min"`
+	Max     int       `json:"max"`
+	Samples int       `json:"samples"`
+}
+
+// ViewerHistoryResponse is the response for the stream history API
+type ViewerHistoryResponse struct {
+	Platform string        `json:"platform"`
+	ID       string        `json:"id"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Step     string        `json:"step"`
+	Points   []ViewerPoint `json:"points"`
+}
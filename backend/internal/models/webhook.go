@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook kinds decide how event payloads are rendered
+const (
+	WebhookGeneric = "generic"
+	WebhookDiscord = "discord"
+	WebhookSlack   = "slack"
+)
+
+// WebhookKinds lists every webhook kind
+var WebhookKinds = []string{WebhookGeneric, WebhookDiscord, WebhookSlack}
+
+// Webhook is an outbound URL notified about stream events
+type Webhook struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Lists     []string  `json:"lists,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookRequest is the request body for registering a webhook.
+// Lists restricts the webhook to follows in those follow lists.
+type WebhookRequest struct {
+	Kind   string   `json:"kind,omitempty"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Lists  []string `json:"lists,omitempty"`
+}
+
+// WebhooksResponse is the response for listing webhooks
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+	Count    int       `json:"count"`
+}
+
+// Delivery records an attempt to deliver an event to a webhook
+type Delivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt,omitempty"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	ReplayOf       string          `json:"replayOf,omitempty"`
+}
+
+// DeliveriesResponse is the response for listing webhook deliveries
+type DeliveriesResponse struct {
+	Deliveries []Delivery `json:"deliveries"`
+	Count      int        `json:"count"`
+}
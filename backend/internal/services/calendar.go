@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"multistream/backend/internal/models"
+)
+
+const (
+	// defaultBroadcastLength is used for broadcasts without a scheduled end
+	defaultBroadcastLength = 2 * time.Hour
+
+	// icsTimeFormat is the UTC date-time form used in iCalendar
+	icsTimeFormat = "20060102T150405Z"
+
+	// icsLineLength is the maximum line length in octets before folding
+	icsLineLength = 75
+)
+
+// Calendar renders the upcoming broadcasts visible to a feed token as an
+// iCalendar (RFC 5545) document
+func (s *FeedService) Calendar(token models.FeedToken) []byte {
+	streams := s.Schedule.Upcoming(token.Lists)
+	now := time.Now().UTC()
+
+	// Broadcasts starting at the same time share a multiview link
+	byStart := make(map[time.Time][]models.Streamer)
+	for _, stream := range streams {
+		start := stream.ScheduledStartTime.Truncate(time.Minute)
+		byStart[start] = append(byStart[start], stream)
+	}
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//MultiStream//Schedule//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText("MultiStream schedule ("+token.Name+")"))
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeICSLine(&b, "X-PUBLISHED-TTL:PT1H")
+
+	for _, stream := range streams {
+		start := stream.ScheduledStartTime.UTC()
+		end := start.Add(defaultBroadcastLength)
+		if stream.ScheduledEndTime != nil && stream.ScheduledEndTime.After(start) {
+			end = stream.ScheduledEndTime.UTC()
+		}
+
+		together := byStart[start.Truncate(time.Minute)]
+		watchURL := MultiviewURL(s.FrontendURL, multiviewStreams(stream, together))
+
+		summary := stream.DisplayName
+		if stream.Title != "" && stream.Title != stream.DisplayName {
+			summary += ": " + stream.Title
+		}
+
+		description := fmt.Sprintf("%s on %s\n\nWatch: %s", stream.DisplayName, stream.Platform, watchURL)
+		if len(together) > 1 {
+			names := make([]string, 0, len(together)-1)
+			for _, other := range together {
+				if other.Platform != stream.Platform || other.ID != stream.ID {
+					names = append(names, other.DisplayName)
+				}
+			}
+			description += "\n\nStarting at the same time: " + strings.Join(names, ", ")
+		}
+
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+calendarUID(stream))
+		writeICSLine(&b, "DTSTAMP:"+now.Format(icsTimeFormat))
+		writeICSLine(&b, "DTSTART:"+start.Format(icsTimeFormat))
+		writeICSLine(&b, "DTEND:"+end.Format(icsTimeFormat))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(summary))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICSText(description))
+		writeICSLine(&b, "URL:"+watchURL)
+		writeICSLine(&b, "STATUS:CONFIRMED")
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// calendarUID identifies a broadcast across feed refreshes, so calendar
+// clients update rescheduled events instead of adding duplicates
+func calendarUID(stream models.Streamer) string {
+	id := stream.BroadcastID
+	if id == "" {
+		id = stream.ID
+	}
+	return fmt.Sprintf("%s-%s@multistream", stream.Platform, id)
+}
+
+// escapeICSText escapes a TEXT property value
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// writeICSLine writes a content line, folding it at icsLineLength octets
+// without splitting UTF-8 sequences
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		// Back up to the start of a UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = icsLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
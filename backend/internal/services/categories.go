@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+)
+
+var categoriesLog = logging.Component("categories")
+
+// categoryListLimit is how many categories are listed from each platform
+const categoryListLimit = 100
+
+// categoryAliases maps names that differ between platforms to one key
+var categoryAliases = map[string]string{
+	"cs2":                          "counterstrike",
+	"csgo":                         "counterstrike",
+	"counterstrike2":               "counterstrike",
+	"counterstrikeglobaloffensive": "counterstrike",
+	"gta5":                         "grandtheftautov",
+	"gtav":                         "grandtheftautov",
+	"grandtheftauto5":              "grandtheftautov",
+	"lol":                          "leagueoflegends",
+	"warzone":                      "callofdutywarzone",
+	"chatting":                     "justchatting",
+}
+
+// platformPreference decides whose spelling a merged category takes
+var platformPreference = []string{"twitch", "kick", "youtube"}
+
+// CategoryService merges the categories of all platforms and lists the live
+// streams in them
+type CategoryService struct {
+	YouTube *YouTubeService
+	Kick    *KickService
+	Twitch  *TwitchService
+	TTL     time.Duration
+
+	mu       sync.Mutex
+	index    map[string]*models.Category
+	listedAt time.Time
+	streams  map[string]topEntry
+}
+
+// NewCategoryService creates a new category service caching results for ttl
+func NewCategoryService(youtube *YouTubeService, kick *KickService, twitch *TwitchService, ttl time.Duration) *CategoryService {
+	return &CategoryService{
+		YouTube: youtube,
+		Kick:    kick,
+		Twitch:  twitch,
+		TTL:     ttl,
+		index:   make(map[string]*models.Category),
+		streams: make(map[string]topEntry),
+	}
+}
+
+// categoryKey reduces a category name or ID to the key it is merged under,
+// so "VALORANT", "Valorant" and "valorant" are one category
+func categoryKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	key := b.String()
+	if alias, ok := categoryAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// categoryID turns a category name into a URL-friendly ID ("Grand Theft Auto V" -> "grand-theft-auto-v")
+func categoryID(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "-")
+}
+
+// List returns categories, optionally only those on platform and matching
+// query, most watched first
+func (s *CategoryService) List(ctx context.Context, platform, query string) ([]models.Category, error) {
+	if err := s.ensureIndex(ctx); err != nil {
+		return nil, err
+	}
+	if query != "" {
+		s.searchTwitch(ctx, query)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	want := categoryKey(query)
+	categories := make([]models.Category, 0)
+	for key, c := range s.index {
+		if platform != "" && platform != "all" {
+			if _, ok := c.Platforms[platform]; !ok {
+				continue
+			}
+		}
+		if want != "" && !strings.Contains(key, want) && !strings.Contains(categoryKey(c.Name), want) {
+			continue
+		}
+		categories = append(categories, cloneCategory(c))
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Viewers != categories[j].Viewers {
+			return categories[i].Viewers > categories[j].Viewers
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	return categories, nil
+}
+
+// Get returns a category by ID or by any platform's name for it
+func (s *CategoryService) Get(ctx context.Context, id string) (*models.Category, error) {
+	if err := s.ensureIndex(ctx); err != nil {
+		return nil, err
+	}
+
+	key := categoryKey(id)
+	if c, ok := s.lookup(key); ok {
+		return c, nil
+	}
+
+	// Not among the listed categories, so ask Twitch for it by name
+	s.searchTwitch(ctx, strings.ReplaceAll(id, "-", " "))
+	if c, ok := s.lookup(key); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("category not found: %s", id)
+}
+
+// CacheSize returns how many category stream lists are cached
+func (s *CategoryService) CacheSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// Streams returns the live streams in a category across platforms, most
+// watched first, optionally in a language
+func (s *CategoryService) Streams(ctx context.Context, id, language string) (*models.Category, []models.Streamer, error) {
+	category, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cacheKey := category.ID + "|" + strings.ToLower(language)
+	s.mu.Lock()
+	entry, ok := s.streams[cacheKey]
+	s.mu.Unlock()
+	hit := ok && time.Since(entry.fetchedAt) < s.TTL
+	cacheLookup(ctx, "category_streams", hit)
+	if hit {
+		return category, entry.streams, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	streams := make([]models.Streamer, 0)
+
+	for platform, pc := range category.Platforms {
+		wg.Add(1)
+		go func(platform string, pc models.PlatformCategory) {
+			defer wg.Done()
+
+			found, err := s.platformStreams(ctx, platform, pc, language)
+			if err != nil {
+				categoriesLog.WarnContext(ctx, "Provider streams failed", "platform", platform, "category", category.ID, "error", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, st := range found {
+				if st.Category == "" {
+					st.Category = category.Name
+				}
+				streams = append(streams, st)
+			}
+		}(platform, pc)
+	}
+	wg.Wait()
+
+	sort.SliceStable(streams, func(i, j int) bool {
+		return streams[i].ViewerCount > streams[j].ViewerCount
+	})
+
+	s.mu.Lock()
+	s.streams[cacheKey] = topEntry{streams: streams, fetchedAt: time.Now().UTC()}
+	for key, entry := range s.streams {
+		if time.Since(entry.fetchedAt) >= s.TTL {
+			delete(s.streams, key)
+		}
+	}
+	s.mu.Unlock()
+
+	return category, streams, nil
+}
+
+// platformStreams lists the live streams of one platform's side of a category
+func (s *CategoryService) platformStreams(ctx context.Context, platform string, pc models.PlatformCategory, language string) ([]models.Streamer, error) {
+	switch platform {
+	case "twitch":
+		if !s.Twitch.Configured() {
+			return nil, nil
+		}
+		return s.Twitch.GetTopStreams(ctx, pc.ID, language, topFetchLimit)
+	case "kick":
+		streams, err := s.Kick.GetLivestreams(ctx, pc.Slug, language, topFetchLimit)
+		if err != nil {
+			return nil, err
+		}
+		return filterCategory(streams, pc.Name), nil
+	case "youtube":
+		if s.YouTube.APIKey == "" {
+			return nil, nil
+		}
+		// Games have no YouTube category ID and are found by name instead
+		topic := ""
+		if pc.ID == "" {
+			topic = pc.Name
+		}
+		return s.YouTube.GetTopLiveInCategory(ctx, topic, pc.ID, language, 50)
+	}
+	return nil, nil
+}
+
+// lookup returns a copy of an indexed category
+func (s *CategoryService) lookup(key string) (*models.Category, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.index[key]
+	if !ok {
+		return nil, false
+	}
+	category := cloneCategory(c)
+	return &category, true
+}
+
+// ensureIndex rebuilds the category index from every platform once it is
+// older than TTL
+func (s *CategoryService) ensureIndex(ctx context.Context) error {
+	s.mu.Lock()
+	fresh := time.Since(s.listedAt) < s.TTL
+	s.mu.Unlock()
+	cacheLookup(ctx, "categories", fresh)
+	if fresh {
+		return nil
+	}
+
+	index := make(map[string]*models.Category)
+	var errs []string
+	sources := 0
+
+	if s.Twitch.Configured() {
+		sources++
+		games, err := s.Twitch.GetTopGames(ctx, categoryListLimit)
+		if err != nil {
+			errs = append(errs, "twitch: "+err.Error())
+		}
+		for _, game := range games {
+			mergeCategory(index, "twitch", models.PlatformCategory{ID: game.ID, Name: game.Name}, twitchBoxArt(game.BoxArtURL), 0)
+		}
+	}
+
+	sources++
+	kickCategories, err := s.Kick.GetCategories(ctx, categoryListLimit)
+	if err != nil {
+		errs = append(errs, "kick: "+err.Error())
+	}
+	for _, kc := range kickCategories {
+		image := kc.Banner.Src
+		if image == "" {
+			image = kc.Banner.URL
+		}
+		pc := models.PlatformCategory{ID: fmt.Sprintf("%d", kc.ID), Name: kc.Name, Slug: kc.Slug}
+		mergeCategory(index, "kick", pc, image, kc.Viewers)
+	}
+
+	if s.YouTube.APIKey != "" {
+		sources++
+		videoCategories, err := s.YouTube.GetVideoCategories(ctx, "US")
+		if err != nil {
+			errs = append(errs, "youtube: "+err.Error())
+		}
+		for _, vc := range videoCategories {
+			mergeCategory(index, "youtube", models.PlatformCategory{ID: vc.ID, Name: vc.Title}, "", 0)
+		}
+	}
+
+	if len(errs) == sources {
+		return fmt.Errorf("all providers failed: %s", strings.Join(errs, "; "))
+	}
+	for _, e := range errs {
+		categoriesLog.WarnContext(ctx, "Provider failed while indexing categories", "error", e)
+	}
+
+	s.finish(index)
+
+	s.mu.Lock()
+	s.index = index
+	s.listedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// searchTwitch adds the Twitch categories matching query to the index
+func (s *CategoryService) searchTwitch(ctx context.Context, query string) {
+	if !s.Twitch.Configured() {
+		return
+	}
+
+	games, err := s.Twitch.SearchCategories(ctx, query, 20)
+	if err != nil {
+		categoriesLog.WarnContext(ctx, "Twitch category search failed", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, game := range games {
+		mergeCategory(s.index, "twitch", models.PlatformCategory{ID: game.ID, Name: game.Name}, twitchBoxArt(game.BoxArtURL), 0)
+	}
+	s.finish(s.index)
+}
+
+// finish settles the names and IDs of merged categories and lets YouTube
+// search for games it has no category for
+func (s *CategoryService) finish(index map[string]*models.Category) {
+	for _, c := range index {
+		for _, platform := range platformPreference {
+			if pc, ok := c.Platforms[platform]; ok {
+				c.Name = pc.Name
+				break
+			}
+		}
+		c.ID = categoryID(c.Name)
+
+		if _, ok := c.Platforms["youtube"]; !ok && s.YouTube.APIKey != "" {
+			c.Platforms["youtube"] = models.PlatformCategory{Name: c.Name}
+		}
+	}
+}
+
+// mergeCategory adds one platform's category to the index entry it aliases
+func mergeCategory(index map[string]*models.Category, platform string, pc models.PlatformCategory, image string, viewers int) {
+	key := categoryKey(pc.Name)
+	if key == "" {
+		return
+	}
+
+	c, ok := index[key]
+	if !ok {
+		c = &models.Category{Name: pc.Name, Platforms: make(map[string]models.PlatformCategory)}
+		index[key] = c
+	}
+
+	if _, seen := c.Platforms[platform]; !seen {
+		c.Viewers += viewers
+	}
+	c.Platforms[platform] = pc
+	if c.Image == "" {
+		c.Image = image
+	}
+}
+
+// cloneCategory copies a category so callers can't touch the index
+func cloneCategory(c *models.Category) models.Category {
+	category := *c
+	category.Platforms = make(map[string]models.PlatformCategory, len(c.Platforms))
+	for platform, pc := range c.Platforms {
+		category.Platforms[platform] = pc
+	}
+	return category
+}
+
+// twitchBoxArt fills in the size placeholders of a box art URL
+func twitchBoxArt(template string) string {
+	return strings.NewReplacer("{width}", "285", "{height}", "380").Replace(template)
+}
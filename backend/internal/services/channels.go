@@ -0,0 +1,529 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var channelsLog = logging.Component("channels")
+
+const (
+	// maxIndexedChannels caps the channel index; the longest unseen go first
+	maxIndexedChannels = 20000
+
+	// channelSaveInterval is how often a changed channel index is saved
+	channelSaveInterval = time.Minute
+
+	// suggestRecencyDecay is how quickly channels not seen lately sink in
+	// suggestions
+	suggestRecencyDecay = 7 * 24 * time.Hour
+
+	// maxIndexedTexts is how many recent titles and categories are kept per channel
+	maxIndexedTexts = 5
+)
+
+// StreamObserver is told about streams a platform service returned
+type StreamObserver func(streams ...models.Streamer)
+
+func (o StreamObserver) notify(streams ...models.Streamer) {
+	if o != nil {
+		o(streams...)
+	}
+}
+
+// indexedChannel is a channel the backend has come across
+type indexedChannel struct {
+	Platform    string `json:"platform"`
+	ChannelID   string `json:"channelId"`
+	DisplayName string `json:"displayName"`
+	Thumbnail   string `json:"thumbnail,omitempty"`
+	IsLive      bool   `json:"isLive,omitempty"`
+	ViewerCount int    `json:"viewerCount,omitempty"`
+	// PeakViewers is the most viewers the channel has been seen live with
+	PeakViewers int       `json:"peakViewers,omitempty"`
+	Seen        int       `json:"seen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	// Titles and Categories are the most recent ones seen, newest first
+	Titles     []string `json:"titles,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+
+	// Search keys of the display name and channel ID
+	nameKey string
+	idKey   string
+	// terms are the weighted terms the channel is indexed under
+	terms map[string]float64
+}
+
+// ChannelIndex remembers every channel the platform services return, so
+// suggestions and, when a platform fails, searches can be served without
+// calling it. Channels are kept on disk; the full-text index over their
+// names, titles and categories is rebuilt from them in memory.
+type ChannelIndex struct {
+	Follows *FollowService
+
+	mu        sync.RWMutex
+	channels  map[string]*indexedChannel
+	postings  map[string]map[string]float64
+	followed  map[string]models.Follow
+	rebuiltAt time.Time
+	dirty     bool
+	file      *store.JSONFile
+}
+
+// NewChannelIndex creates a new channel index backed by a file in dataDir
+func NewChannelIndex(follows *FollowService, dataDir string) *ChannelIndex {
+	idx := &ChannelIndex{
+		Follows:  follows,
+		channels: make(map[string]*indexedChannel),
+		postings: make(map[string]map[string]float64),
+		followed: make(map[string]models.Follow),
+		file:     store.NewJSONFile(dataDir, "channels.json"),
+	}
+	idx.file.Compact = true
+
+	// Changes from the watcher are newer than the list taken after it
+	follows.AddWatcher(idx.watchFollow)
+	for _, f := range follows.List() {
+		idx.mu.Lock()
+		if _, ok := idx.followed[f.ID]; !ok {
+			idx.followed[f.ID] = f
+		}
+		idx.mu.Unlock()
+	}
+
+	var stored []*indexedChannel
+	if err := idx.file.Load(&stored); err != nil {
+		channelsLog.Error("Failed to load channel index", "error", err)
+	}
+	for _, ch := range stored {
+		key := models.FollowID(ch.Platform, ch.ChannelID)
+		idx.channels[key] = ch
+		idx.index(key, ch)
+	}
+	idx.rebuiltAt = time.Now().UTC()
+
+	return idx
+}
+
+// Run saves the index every so often while it changes, until ctx is done
+func (idx *ChannelIndex) Run(ctx context.Context) {
+	ticker := time.NewTicker(channelSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			idx.save()
+			return
+		case <-ticker.C:
+			idx.save()
+		}
+	}
+}
+
+// Observe records the channels behind streams returned by a platform. The
+// channel is the username: YouTube channel ID, Kick slug or Twitch login.
+// It is meant to be set as the platform services' Observer.
+func (idx *ChannelIndex) Observe(streams ...models.Streamer) {
+	now := time.Now().UTC()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, st := range streams {
+		if st.Platform == "" || st.Username == "" {
+			continue
+		}
+
+		key := models.FollowID(st.Platform, st.Username)
+		ch, ok := idx.channels[key]
+		if !ok {
+			ch = &indexedChannel{Platform: st.Platform, ChannelID: st.Username}
+			idx.channels[key] = ch
+		}
+
+		if st.DisplayName != "" {
+			ch.DisplayName = st.DisplayName
+		}
+		if st.Thumbnail != "" {
+			ch.Thumbnail = st.Thumbnail
+		}
+		// Offline YouTube videos carry lifetime views, not viewers
+		ch.IsLive = st.IsLive
+		ch.ViewerCount = liveViewers(st)
+		ch.PeakViewers = max(ch.PeakViewers, ch.ViewerCount)
+		ch.Seen++
+		ch.LastSeen = now
+		ch.Titles = pushRecent(ch.Titles, st.Title)
+		ch.Categories = pushRecent(ch.Categories, st.Category)
+		idx.index(key, ch)
+	}
+	idx.dirty = true
+}
+
+// watchFollow keeps the followed channels current
+func (idx *ChannelIndex) watchFollow(f models.Follow, followed bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if followed {
+		idx.followed[f.ID] = f
+	} else {
+		delete(idx.followed, f.ID)
+	}
+}
+
+// Suggest returns up to limit channels matching what has been typed so far,
+// optionally only on platform. Channels match by prefix first, then by a
+// word of their name, by substring and finally within a few typos, and rank
+// by that, their popularity, how recently they were seen and being followed.
+func (idx *ChannelIndex) Suggest(query, platform string, limit int) []models.Suggestion {
+	want := searchKey(query)
+	if want == "" {
+		return []models.Suggestion{}
+	}
+	words := strings.Fields(strings.ToLower(query))
+
+	type scored struct {
+		suggestion models.Suggestion
+		score      float64
+	}
+	var matches []scored
+	now := time.Now()
+
+	// Matching, with its edit distances, happens on copies outside the lock
+	idx.mu.RLock()
+	candidates := idx.suggestCandidates(want, words, platform)
+	idx.mu.RUnlock()
+
+	for _, c := range candidates {
+		ch := c.channel
+		match := suggestMatch(want, words, ch)
+		if match == 0 {
+			continue
+		}
+
+		f, isFollowed := c.follow, c.followed
+		if isFollowed && f.Status != nil {
+			ch.IsLive, ch.ViewerCount = f.Status.IsLive, f.Status.ViewerCount
+		}
+
+		popularity := math.Min(math.Log10(float64(ch.PeakViewers)+1)/viewerCeiling, 1)
+		recency := math.Exp(-float64(now.Sub(ch.LastSeen)) / float64(suggestRecencyDecay))
+		score := 0.5*match + 0.2*popularity + 0.2*recency
+		if isFollowed {
+			score += 0.1
+		}
+
+		matches = append(matches, scored{
+			suggestion: models.Suggestion{
+				Platform:    ch.Platform,
+				ChannelID:   ch.ChannelID,
+				DisplayName: ch.DisplayName,
+				Thumbnail:   ch.Thumbnail,
+				IsLive:      ch.IsLive,
+				ViewerCount: ch.ViewerCount,
+				Followed:    isFollowed,
+				LastSeen:    ch.LastSeen,
+			},
+			score: score,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].suggestion.DisplayName < matches[j].suggestion.DisplayName
+	})
+
+	suggestions := make([]models.Suggestion, 0, min(limit, len(matches)))
+	for i := 0; i < len(matches) && i < limit; i++ {
+		suggestions = append(suggestions, matches[i].suggestion)
+	}
+	return suggestions
+}
+
+// suggestCandidate is a channel that may match a suggestion query
+type suggestCandidate struct {
+	channel  indexedChannel
+	follow   models.Follow
+	followed bool
+}
+
+// suggestCandidates picks the channels on platform (all when empty or
+// "all") that suggestMatch could match, from the terms of their names: terms
+// the query starts with or that start with or contain its first word, and,
+// when typos are allowed, terms close enough to the query. Follows count
+// even if no search or lookup has shown them yet. The caller holds mu.
+func (idx *ChannelIndex) suggestCandidates(want string, words []string, platform string) []suggestCandidate {
+	first := want
+	if len(words) > 0 && searchKey(words[0]) != "" {
+		first = searchKey(words[0])
+	}
+	typos := typoAllowance(want)
+
+	keys := make(map[string]bool)
+	for term, docs := range idx.postings {
+		if !strings.HasPrefix(want, term) && !strings.Contains(term, first) &&
+			(typos == 0 || !typoCandidate(want, term, typos)) {
+			continue
+		}
+		for key, weight := range docs {
+			if weight >= nameTermWeight {
+				keys[key] = true
+			}
+		}
+	}
+	for key := range idx.followed {
+		if _, ok := idx.channels[key]; !ok {
+			keys[key] = true
+		}
+	}
+
+	candidates := make([]suggestCandidate, 0, len(keys))
+	for key := range keys {
+		f, followed := idx.followed[key]
+		var ch indexedChannel
+		if indexed, ok := idx.channels[key]; ok {
+			ch = *indexed
+		} else {
+			ch = indexedChannel{
+				Platform:    f.Platform,
+				ChannelID:   f.ChannelID,
+				DisplayName: f.DisplayName,
+				LastSeen:    f.AddedAt,
+				nameKey:     searchKey(f.DisplayName),
+				idKey:       searchKey(f.ChannelID),
+			}
+			if f.LastChecked != nil {
+				ch.LastSeen = *f.LastChecked
+			}
+			if f.Status != nil {
+				ch.Thumbnail = f.Status.Thumbnail
+				ch.PeakViewers = liveViewers(*f.Status)
+			}
+		}
+
+		if platform != "" && platform != "all" && ch.Platform != platform {
+			continue
+		}
+		candidates = append(candidates, suggestCandidate{channel: ch, follow: f, followed: followed})
+	}
+	return candidates
+}
+
+// typoCandidate reports whether term could start within typos edits of
+// query, from the pairs of adjacent letters they share, as each edit changes
+// at most two. It is a cheap filter ahead of the edit distance that takes
+// the first letter to be right.
+func typoCandidate(query, term string, typos int) bool {
+	q, t := []rune(query), []rune(term)
+	if len(q) == 0 || len(t) == 0 || q[0] != t[0] {
+		return false
+	}
+	window := t[:min(len(t), len(q)+typos)]
+	q = q[:min(len(q), len(t))]
+
+	shared := 0
+	for i := 1; i < len(q); i++ {
+		for j := 1; j < len(window); j++ {
+			if q[i-1] == window[j-1] && q[i] == window[j] {
+				shared++
+				break
+			}
+		}
+	}
+	return shared >= len(q)-1-2*typos
+}
+
+// suggestMatch rates how well a channel matches a partly typed query, from
+// 1 for a name starting with it down to 0 for no match
+func suggestMatch(want string, words []string, ch indexedChannel) float64 {
+	if strings.HasPrefix(ch.nameKey, want) || strings.HasPrefix(ch.idKey, want) {
+		return 1
+	}
+
+	// A later word of the name, such as "clips" in "xQc Clips"
+	if nameWords := strings.Fields(strings.ToLower(ch.DisplayName)); len(words) > 0 && len(nameWords) > 1 {
+		for _, word := range nameWords[1:] {
+			if strings.HasPrefix(searchKey(word), searchKey(words[0])) {
+				return 0.8
+			}
+		}
+	}
+
+	if strings.Contains(ch.nameKey, want) || strings.Contains(ch.idKey, want) {
+		return 0.6
+	}
+
+	allowed := typoAllowance(want)
+	if allowed == 0 {
+		return 0
+	}
+	distance := min(prefixDistance(want, ch.nameKey), prefixDistance(want, ch.idKey))
+	if distance > allowed {
+		return 0
+	}
+	return 0.5 - 0.1*float64(distance)
+}
+
+// Similar returns the IDs of up to limit channels on platform whose ID is
+// within a few typos of text, closest and then most popular first
+func (idx *ChannelIndex) Similar(platform, text string, limit int) []string {
+	want := searchKey(text)
+	allowed := typoAllowance(want)
+	if allowed == 0 {
+		return nil
+	}
+
+	type candidate struct {
+		id       string
+		distance int
+		viewers  int
+	}
+	var candidates []candidate
+
+	idx.mu.RLock()
+	for _, ch := range idx.channels {
+		if ch.Platform != platform {
+			continue
+		}
+		if d := editDistance(want, ch.idKey); d <= allowed {
+			candidates = append(candidates, candidate{id: ch.ChannelID, distance: d, viewers: ch.PeakViewers})
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].viewers > candidates[j].viewers
+	})
+
+	ids := make([]string, 0, min(limit, len(candidates)))
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		ids = append(ids, candidates[i].id)
+	}
+	return ids
+}
+
+// typoAllowance is how many typos a query of this length may contain
+func typoAllowance(query string) int {
+	switch n := len([]rune(query)); {
+	case n < 3:
+		return 0
+	case n < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	return levenshtein([]rune(a), []rune(b))[len([]rune(b))]
+}
+
+// prefixDistance is the edit distance between query and the closest prefix
+// of text, so an unfinished name still matches
+func prefixDistance(query, text string) int {
+	q, t := []rune(query), []rune(text)
+	if len(t) == 0 {
+		return len(q)
+	}
+
+	best := len(q)
+	for _, d := range levenshtein(q, t) {
+		best = min(best, d)
+	}
+	return best
+}
+
+// levenshtein returns the edit distances from all of q to every prefix of t
+func levenshtein(q, t []rune) []int {
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(q); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if q[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev
+}
+
+// pushRecent puts text at the front of a most-recent-first list, without
+// duplicates and up to maxIndexedTexts long
+func pushRecent(list []string, text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return list
+	}
+
+	recent := make([]string, 0, min(len(list)+1, maxIndexedTexts))
+	recent = append(recent, text)
+	for _, existing := range list {
+		if len(recent) == maxIndexedTexts {
+			break
+		}
+		if !strings.EqualFold(existing, text) {
+			recent = append(recent, existing)
+		}
+	}
+	return recent
+}
+
+// save trims the index to its cap and writes it out if it changed
+func (idx *ChannelIndex) save() {
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return
+	}
+
+	channels := make([]*indexedChannel, 0, len(idx.channels))
+	for _, ch := range idx.channels {
+		channels = append(channels, ch)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].LastSeen.After(channels[j].LastSeen)
+	})
+	if len(channels) > maxIndexedChannels {
+		for _, ch := range channels[maxIndexedChannels:] {
+			key := models.FollowID(ch.Platform, ch.ChannelID)
+			idx.unindex(key, ch)
+			delete(idx.channels, key)
+		}
+		channels = channels[:maxIndexedChannels]
+	}
+
+	stored := make([]indexedChannel, 0, len(channels))
+	for _, ch := range channels {
+		stored = append(stored, *ch)
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	if err := idx.file.Save(stored); err != nil {
+		channelsLog.Error("Failed to save channel index", "error", err)
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"multistream/backend/internal/models"
+)
+
+// Weights of the fields of an indexed channel in full-text search
+const (
+	nameTermWeight     = 3
+	categoryTermWeight = 2
+	titleTermWeight    = 1
+)
+
+// tokenize splits text into lowercase words of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// index (re)adds a channel to the full-text index. The caller holds mu.
+func (idx *ChannelIndex) index(key string, ch *indexedChannel) {
+	idx.unindex(key, ch)
+
+	ch.nameKey, ch.idKey = searchKey(ch.DisplayName), searchKey(ch.ChannelID)
+
+	terms := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, term := range tokenize(text) {
+			terms[term] = max(terms[term], weight)
+		}
+	}
+	add(ch.DisplayName, nameTermWeight)
+	add(ch.ChannelID, nameTermWeight)
+	for _, category := range ch.Categories {
+		add(category, categoryTermWeight)
+	}
+	for _, title := range ch.Titles {
+		add(title, titleTermWeight)
+	}
+
+	for term, weight := range terms {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[string]float64)
+			idx.postings[term] = docs
+		}
+		docs[key] = weight
+	}
+	ch.terms = terms
+}
+
+// unindex removes a channel from the full-text index. The caller holds mu.
+func (idx *ChannelIndex) unindex(key string, ch *indexedChannel) {
+	for term := range ch.terms {
+		docs := idx.postings[term]
+		delete(docs, key)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	ch.terms = nil
+}
+
+// Search finds indexed channels on the given platforms (all when empty)
+// that contain every word of query, the last one as a prefix. Matches in
+// names count most, then categories, then titles; ties go to the more
+// popular channel. Live status and viewers are as last seen.
+func (idx *ChannelIndex) Search(query string, platforms []string, limit int) []models.Streamer {
+	words := tokenize(query)
+	if len(words) == 0 || limit <= 0 {
+		return []models.Streamer{}
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var scores map[string]float64
+	for i, word := range words {
+		matched := make(map[string]float64)
+		for key, weight := range idx.postings[word] {
+			matched[key] = weight
+		}
+		// The last word may still be being typed
+		if i == len(words)-1 {
+			for term, docs := range idx.postings {
+				if term == word || !strings.HasPrefix(term, word) {
+					continue
+				}
+				for key, weight := range docs {
+					matched[key] = max(matched[key], weight)
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for key := range scores {
+			if weight, ok := matched[key]; ok {
+				scores[key] += weight
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	found := make([]*indexedChannel, 0, len(scores))
+	for key := range scores {
+		ch := idx.channels[key]
+		if len(platforms) == 0 || slices.Contains(platforms, ch.Platform) {
+			found = append(found, ch)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		sa, sb := scores[models.FollowID(a.Platform, a.ChannelID)], scores[models.FollowID(b.Platform, b.ChannelID)]
+		if sa != sb {
+			return sa > sb
+		}
+		if a.PeakViewers != b.PeakViewers {
+			return a.PeakViewers > b.PeakViewers
+		}
+		return a.LastSeen.After(b.LastSeen)
+	})
+
+	streams := make([]models.Streamer, 0, min(limit, len(found)))
+	for i := 0; i < len(found) && i < limit; i++ {
+		streams = append(streams, found[i].streamer())
+	}
+	return streams
+}
+
+// streamer describes an indexed channel the way the platforms do
+func (ch *indexedChannel) streamer() models.Streamer {
+	st := models.Streamer{
+		ID:          ch.ChannelID,
+		Platform:    ch.Platform,
+		Username:    ch.ChannelID,
+		DisplayName: ch.DisplayName,
+		Thumbnail:   ch.Thumbnail,
+		Title:       ch.DisplayName,
+		ViewerCount: ch.ViewerCount,
+		IsLive:      ch.IsLive,
+		State:       models.LiveState(ch.IsLive),
+	}
+	if len(ch.Titles) > 0 {
+		st.Title = ch.Titles[0]
+	}
+	if len(ch.Categories) > 0 {
+		st.Category = ch.Categories[0]
+	}
+
+	switch ch.Platform {
+	case "youtube":
+		st.EmbedURL = fmt.Sprintf("https://www.youtube.com/embed/live_stream?channel=%s&autoplay=1", ch.ChannelID)
+	case "kick":
+		st.EmbedURL = fmt.Sprintf("https://player.kick.com/%s", ch.ChannelID)
+		st.ChatURL = fmt.Sprintf("https://kick.com/%s/chatroom", ch.ChannelID)
+	case "twitch":
+		st.EmbedURL = twitchEmbedURL(ch.ChannelID)
+		st.ChatURL = twitchChatURL(ch.ChannelID)
+	}
+	return st
+}
+
+// Stats describes the size of the index
+func (idx *ChannelIndex) Stats() models.ChannelIndexStats {
+	idx.mu.RLock()
+	stats := models.ChannelIndexStats{
+		Channels:  len(idx.channels),
+		Platforms: make(map[string]int),
+		Terms:     len(idx.postings),
+		RebuiltAt: idx.rebuiltAt,
+	}
+	for _, ch := range idx.channels {
+		stats.Platforms[ch.Platform]++
+	}
+	for _, docs := range idx.postings {
+		stats.Postings += len(docs)
+	}
+	idx.mu.RUnlock()
+
+	if idx.file.Path != "" {
+		if info, err := os.Stat(idx.file.Path); err == nil {
+			stats.FileBytes = info.Size()
+		}
+	}
+	return stats
+}
+
+// Rebuild adds the last known status of every follow, rebuilds the
+// full-text index from the stored channels and saves them
+func (idx *ChannelIndex) Rebuild() models.ChannelIndexStats {
+	var statuses []models.Streamer
+	for _, f := range idx.Follows.List() {
+		if f.Status != nil {
+			statuses = append(statuses, *f.Status)
+		}
+	}
+	idx.Observe(statuses...)
+
+	idx.mu.Lock()
+	idx.postings = make(map[string]map[string]float64)
+	for key, ch := range idx.channels {
+		ch.terms = nil
+		idx.index(key, ch)
+	}
+	idx.rebuiltAt = time.Now().UTC()
+	idx.dirty = true
+	idx.mu.Unlock()
+
+	idx.save()
+	return idx.Stats()
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by us, were
+// altered, or belong to a different search
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorSigner seals pagination state into opaque cursors. A cursor is the
+// state as base64url JSON followed by its HMAC-SHA256, so clients can hand
+// it back but can't forge or edit it.
+type CursorSigner struct {
+	key []byte
+}
+
+// NewCursorSigner creates a cursor signer keyed with secret
+func NewCursorSigner(secret string) *CursorSigner {
+	return &CursorSigner{key: []byte(secret)}
+}
+
+// Encode seals state into a cursor
+func (c *CursorSigner) Encode(state interface{}) (string, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies a cursor and unpacks its state
+func (c *CursorSigner) Decode(cursor string, state interface{}) error {
+	payload, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+
+	expected, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(c.sign(payload), expected) {
+		return ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (c *CursorSigner) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"multistream/backend/internal/models"
+)
+
+// EventBus fans stream events out to subscribers
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers []func(models.StreamEvent)
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a handler for every published event.
+// Handlers are called synchronously and must not block.
+func (b *EventBus) Subscribe(handler func(models.StreamEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish delivers an event to all subscribers
+func (b *EventBus) Publish(event models.StreamEvent) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// newID returns a random hex identifier
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
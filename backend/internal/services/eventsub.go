@@ -0,0 +1,536 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var eventsubLog = logging.Component("eventsub")
+
+// EventSub message types and headers
+const (
+	eventSubVerification = "webhook_callback_verification"
+	eventSubNotification = "notification"
+	eventSubRevocation   = "revocation"
+
+	EventSubHeaderID        = "Twitch-Eventsub-Message-Id"
+	EventSubHeaderTimestamp = "Twitch-Eventsub-Message-Timestamp"
+	EventSubHeaderSignature = "Twitch-Eventsub-Message-Signature"
+	EventSubHeaderType      = "Twitch-Eventsub-Message-Type"
+)
+
+const (
+	// eventSubMaxAge is how far a message's timestamp may be from now, in
+	// either direction, before it is rejected as a replay; message IDs are
+	// remembered for the same window
+	eventSubMaxAge = 10 * time.Minute
+
+	// eventSubSyncInterval is how often subscriptions are reconciled with follows
+	eventSubSyncInterval = time.Minute
+)
+
+// eventSubTypes are the subscription types created for every followed broadcaster
+var eventSubTypes = []string{"stream.online", "stream.offline"}
+
+// EventSubSubscription tracks the EventSub subscriptions of one followed broadcaster
+type EventSubSubscription struct {
+	Login           string            `json:"login"`
+	BroadcasterID   string            `json:"broadcasterId"`
+	SubscriptionIDs map[string]string `json:"subscriptionIds"`
+	Verified        map[string]bool   `json:"verified"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	LastEventAt     *time.Time        `json:"lastEventAt,omitempty"`
+}
+
+// clone returns a deep copy that is safe to use without holding the service lock
+func (sub *EventSubSubscription) clone() *EventSubSubscription {
+	copied := *sub
+	copied.SubscriptionIDs = make(map[string]string, len(sub.SubscriptionIDs))
+	for k, v := range sub.SubscriptionIDs {
+		copied.SubscriptionIDs[k] = v
+	}
+	copied.Verified = make(map[string]bool, len(sub.Verified))
+	for k, v := range sub.Verified {
+		copied.Verified[k] = v
+	}
+	return &copied
+}
+
+// eventSubState is the persisted state of the EventSub service
+type eventSubState struct {
+	Secret        string                  `json:"secret"`
+	Subscriptions []*EventSubSubscription `json:"subscriptions"`
+}
+
+// EventSubResult is what the HTTP handler should answer Twitch with
+type EventSubResult struct {
+	Status      int
+	Body        string
+	ContentType string
+}
+
+// EventSubService receives Twitch EventSub webhooks for followed broadcasters
+// and turns stream.online/offline into follow status changes
+type EventSubService struct {
+	Twitch      *TwitchService
+	Follows     *FollowService
+	CallbackURL string
+
+	mu     sync.Mutex
+	secret string
+	subs   map[string]*EventSubSubscription
+	seen   map[string]time.Time
+	file   *store.JSONFile
+}
+
+// NewEventSubService creates a new EventSub service. When secret is empty a
+// random one is generated and stored in dataDir.
+func NewEventSubService(twitch *TwitchService, follows *FollowService, callbackURL, secret, dataDir string) *EventSubService {
+	s := &EventSubService{
+		Twitch:      twitch,
+		Follows:     follows,
+		CallbackURL: callbackURL,
+		subs:        make(map[string]*EventSubSubscription),
+		seen:        make(map[string]time.Time),
+		file:        store.NewJSONFile(dataDir, "eventsub.json"),
+	}
+
+	var state eventSubState
+	if err := s.file.Load(&state); err != nil {
+		eventsubLog.Error("Failed to load subscriptions", "error", err)
+	}
+	for _, sub := range state.Subscriptions {
+		s.subs[sub.Login] = sub.clone()
+	}
+
+	s.secret = secret
+	if s.secret == "" {
+		s.secret = state.Secret
+	}
+	if s.secret == "" {
+		s.secret = newID()
+	}
+
+	return s
+}
+
+// IsActive reports whether go-live for a follow is pushed through EventSub
+func (s *EventSubService) IsActive(f models.Follow) bool {
+	if f.Platform != "twitch" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[f.ChannelID]
+	return ok && sub.Verified["stream.online"]
+}
+
+// Subscriptions returns the subscriptions of all followed broadcasters
+func (s *EventSubService) Subscriptions() []EventSubSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]EventSubSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, *sub.clone())
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Login < subs[j].Login
+	})
+	return subs
+}
+
+// Run keeps EventSub subscriptions in line with followed Twitch channels until ctx is done
+func (s *EventSubService) Run(ctx context.Context) {
+	ticker := time.NewTicker(eventSubSyncInterval)
+	defer ticker.Stop()
+
+	s.Sync(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sync(ctx)
+		}
+	}
+}
+
+// Sync creates subscriptions for new Twitch follows and deletes those of
+// channels that are no longer followed
+func (s *EventSubService) Sync(ctx context.Context) {
+	followed := make(map[string]bool)
+	for _, f := range s.Follows.List() {
+		if f.Platform == "twitch" {
+			followed[f.ChannelID] = true
+		}
+	}
+
+	s.mu.Lock()
+	var create, remove []string
+	for login := range followed {
+		if sub, ok := s.subs[login]; !ok || len(sub.SubscriptionIDs) < len(eventSubTypes) {
+			create = append(create, login)
+		}
+	}
+	for login := range s.subs {
+		if !followed[login] {
+			remove = append(remove, login)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, login := range create {
+		if err := s.subscribe(ctx, login); err != nil {
+			eventsubLog.WarnContext(ctx, "Failed to subscribe", "login", login, "error", err)
+		}
+	}
+	for _, login := range remove {
+		s.unsubscribe(ctx, login)
+	}
+
+	if len(create) > 0 || len(remove) > 0 {
+		s.save()
+	}
+}
+
+// subscribe creates the missing subscriptions for a broadcaster
+func (s *EventSubService) subscribe(ctx context.Context, login string) error {
+	s.mu.Lock()
+	sub, ok := s.subs[login]
+	if !ok {
+		sub = &EventSubSubscription{
+			Login:           login,
+			SubscriptionIDs: make(map[string]string),
+			Verified:        make(map[string]bool),
+			CreatedAt:       time.Now().UTC(),
+		}
+		s.subs[login] = sub
+	}
+	broadcasterID := sub.BroadcasterID
+	s.mu.Unlock()
+
+	if broadcasterID == "" {
+		user, err := s.Twitch.GetUser(ctx, login)
+		if err != nil {
+			return err
+		}
+		broadcasterID = user.ID
+
+		s.mu.Lock()
+		sub.BroadcasterID = broadcasterID
+		s.mu.Unlock()
+	}
+
+	for _, eventType := range eventSubTypes {
+		s.mu.Lock()
+		_, exists := sub.SubscriptionIDs[eventType]
+		s.mu.Unlock()
+		if exists {
+			continue
+		}
+
+		id, err := s.Twitch.CreateEventSubSubscription(ctx, eventType, broadcasterID, s.CallbackURL, s.secret)
+		var apiErr *TwitchAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			// Created before our state was saved, or by another instance
+			// sharing the callback; adopt it rather than retrying forever
+			if err := s.adopt(ctx, sub, eventType); err != nil {
+				return fmt.Errorf("%s: %w", eventType, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", eventType, err)
+		}
+
+		s.mu.Lock()
+		sub.SubscriptionIDs[eventType] = id
+		s.mu.Unlock()
+		eventsubLog.InfoContext(ctx, "Subscribed", "login", login, "type", eventType)
+	}
+	return nil
+}
+
+// adopt records the existing subscription Twitch answered a create with
+// 409 Conflict for
+func (s *EventSubService) adopt(ctx context.Context, sub *EventSubSubscription, eventType string) error {
+	s.mu.Lock()
+	broadcasterID := sub.BroadcasterID
+	s.mu.Unlock()
+
+	existing, err := s.Twitch.GetEventSubSubscriptions(ctx, broadcasterID)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.Type != eventType || e.Condition.BroadcasterUserID != broadcasterID || e.Transport.Callback != s.CallbackURL {
+			continue
+		}
+
+		s.mu.Lock()
+		sub.SubscriptionIDs[eventType] = e.ID
+		if e.Status == "enabled" {
+			sub.Verified[eventType] = true
+		}
+		s.mu.Unlock()
+		eventsubLog.InfoContext(ctx, "Adopted existing subscription", "login", sub.Login, "type", eventType, "status", e.Status)
+		return nil
+	}
+	return fmt.Errorf("twitch reported a conflicting subscription that isn't listed")
+}
+
+// unsubscribe deletes the subscriptions of a broadcaster that is no longer
+// followed. IDs are forgotten only once Twitch has deleted them, so the
+// next sync retries those that failed.
+func (s *EventSubService) unsubscribe(ctx context.Context, login string) {
+	s.mu.Lock()
+	sub, ok := s.subs[login]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	ids := sub.clone().SubscriptionIDs
+	s.mu.Unlock()
+
+	for eventType, id := range ids {
+		err := s.Twitch.DeleteEventSubSubscription(ctx, id)
+		// Not found means it is already gone, by revocation or by hand
+		var apiErr *TwitchAPIError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+			eventsubLog.WarnContext(ctx, "Failed to delete subscription", "subscription", id, "error", err)
+			continue
+		}
+
+		s.mu.Lock()
+		delete(sub.SubscriptionIDs, eventType)
+		delete(sub.Verified, eventType)
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	done := len(sub.SubscriptionIDs) == 0 && s.subs[login] == sub
+	if done {
+		delete(s.subs, login)
+	}
+	s.mu.Unlock()
+	if done {
+		eventsubLog.InfoContext(ctx, "Unsubscribed", "login", login)
+	}
+}
+
+// eventSubMessage is the body of every EventSub webhook message
+type eventSubMessage struct {
+	Challenge    string `json:"challenge"`
+	Subscription struct {
+		ID        string `json:"id"`
+		Type      string `json:"type"`
+		Status    string `json:"status"`
+		Condition struct {
+			BroadcasterUserID string `json:"broadcaster_user_id"`
+		} `json:"condition"`
+	} `json:"subscription"`
+	Event struct {
+		BroadcasterUserID    string `json:"broadcaster_user_id"`
+		BroadcasterUserLogin string `json:"broadcaster_user_login"`
+		BroadcasterUserName  string `json:"broadcaster_user_name"`
+		Type                 string `json:"type"`
+		StartedAt            string `json:"started_at"`
+	} `json:"event"`
+}
+
+// Handle verifies and processes an EventSub webhook message
+func (s *EventSubService) Handle(ctx context.Context, header http.Header, body []byte) EventSubResult {
+	messageID := header.Get(EventSubHeaderID)
+	timestamp := header.Get(EventSubHeaderTimestamp)
+
+	if !s.verifySignature(messageID, timestamp, header.Get(EventSubHeaderSignature), body) {
+		return EventSubResult{Status: http.StatusForbidden, Body: "invalid signature"}
+	}
+
+	sent, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil || time.Since(sent).Abs() > eventSubMaxAge {
+		return EventSubResult{Status: http.StatusForbidden, Body: "stale message"}
+	}
+
+	// Twitch resends messages it isn't sure we got; acknowledge duplicates
+	if s.markSeen(messageID) {
+		return EventSubResult{Status: http.StatusNoContent}
+	}
+
+	var msg eventSubMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return EventSubResult{Status: http.StatusBadRequest, Body: "invalid body"}
+	}
+
+	switch header.Get(EventSubHeaderType) {
+	case eventSubVerification:
+		s.setVerified(msg.Subscription.Condition.BroadcasterUserID, msg.Subscription.Type)
+		return EventSubResult{Status: http.StatusOK, Body: msg.Challenge, ContentType: "text/plain"}
+	case eventSubNotification:
+		go s.handleNotification(context.WithoutCancel(ctx), msg)
+		return EventSubResult{Status: http.StatusNoContent}
+	case eventSubRevocation:
+		s.revoke(msg.Subscription.ID, msg.Subscription.Status)
+		return EventSubResult{Status: http.StatusNoContent}
+	default:
+		return EventSubResult{Status: http.StatusBadRequest, Body: "unknown message type"}
+	}
+}
+
+// verifySignature checks the HMAC-SHA256 of message ID, timestamp and body
+func (s *EventSubService) verifySignature(messageID, timestamp, signature string, body []byte) bool {
+	if messageID == "" || timestamp == "" || len(signature) < 7 || signature[:7] != "sha256=" {
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature[7:])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.secret))
+	mac.Write([]byte(messageID))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// markSeen records a message ID and reports whether it was already seen
+func (s *EventSubService) markSeen(messageID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, at := range s.seen {
+		if now.Sub(at) > eventSubMaxAge {
+			delete(s.seen, id)
+		}
+	}
+
+	if _, ok := s.seen[messageID]; ok {
+		return true
+	}
+	s.seen[messageID] = now
+	return false
+}
+
+// handleNotification applies a stream.online or stream.offline event to the follow
+func (s *EventSubService) handleNotification(ctx context.Context, msg eventSubMessage) {
+	login := msg.Event.BroadcasterUserLogin
+
+	now := time.Now().UTC()
+	s.mu.Lock()
+	if sub, ok := s.subs[login]; ok {
+		sub.LastEventAt = &now
+	}
+	s.mu.Unlock()
+
+	f, ok := s.Follows.Get("twitch", login)
+	if !ok {
+		return
+	}
+
+	eventsubLog.InfoContext(ctx, "Notification", "type", msg.Subscription.Type, "login", login)
+
+	switch msg.Subscription.Type {
+	case "stream.online":
+		// The event has no title or viewers, so look the stream up
+		status, err := s.Twitch.GetChannelInfo(ctx, login)
+		if err != nil || !status.IsLive {
+			// Helix can lag behind the event; keep the last known title
+			// rather than inventing one
+			status = &models.Streamer{
+				ID:          login,
+				Platform:    "twitch",
+				Username:    login,
+				DisplayName: msg.Event.BroadcasterUserName,
+				IsLive:      true,
+				State:       models.StateLive,
+				EmbedURL:    twitchEmbedURL(login),
+				ChatURL:     twitchChatURL(login),
+			}
+			if f.Status != nil {
+				status.Title = f.Status.Title
+			}
+		}
+		s.Follows.UpdateStatus("twitch", login, status)
+	case "stream.offline":
+		status := models.Streamer{
+			ID:          login,
+			Platform:    "twitch",
+			Username:    login,
+			DisplayName: msg.Event.BroadcasterUserName,
+			IsLive:      false,
+			State:       models.StateOffline,
+			EmbedURL:    twitchEmbedURL(login),
+			ChatURL:     twitchChatURL(login),
+		}
+		if f.Status != nil {
+			status.Thumbnail = f.Status.Thumbnail
+			status.Title = f.Status.Title
+		}
+		s.Follows.UpdateStatus("twitch", login, &status)
+	}
+}
+
+// setVerified marks a subscription as confirmed by its verification challenge.
+// Twitch verifies while the create request is still in flight, so the
+// subscription is matched by broadcaster rather than by ID.
+func (s *EventSubService) setVerified(broadcasterID, eventType string) {
+	s.mu.Lock()
+	for _, sub := range s.subs {
+		if sub.BroadcasterID == broadcasterID {
+			sub.Verified[eventType] = true
+			eventsubLog.Info("Verified", "type", eventType, "login", sub.Login)
+		}
+	}
+	s.mu.Unlock()
+	s.save()
+}
+
+// revoke forgets a subscription Twitch revoked so the next sync recreates it
+// if the broadcaster is still followed
+func (s *EventSubService) revoke(subscriptionID, reason string) {
+	s.mu.Lock()
+	for _, sub := range s.subs {
+		for eventType, id := range sub.SubscriptionIDs {
+			if id == subscriptionID {
+				delete(sub.SubscriptionIDs, eventType)
+				delete(sub.Verified, eventType)
+				eventsubLog.Warn("Subscription revoked", "type", eventType, "login", sub.Login, "reason", reason)
+			}
+		}
+	}
+	s.mu.Unlock()
+	s.save()
+}
+
+func (s *EventSubService) save() {
+	s.mu.Lock()
+	state := eventSubState{Secret: s.secret}
+	for _, sub := range s.subs {
+		state.Subscriptions = append(state.Subscriptions, sub.clone())
+	}
+	s.mu.Unlock()
+
+	if err := s.file.Save(state); err != nil {
+		eventsubLog.Error("Failed to save subscriptions", "error", err)
+	}
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"testing"
+	"time"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+const (
+	eventSubTestSecret      = "eventsub-test-secret"
+	eventSubTestBroadcaster = "71092938"
+)
+
+// eventSubTest wires an EventSub service to the Twitch stand-in, with xqc
+// followed and offline
+type eventSubTest struct {
+	service *EventSubService
+	follows *FollowService
+	twitch  *fakeTwitch
+	events  chan models.StreamEvent
+}
+
+func newEventSubTest(t *testing.T) *eventSubTest {
+	t.Helper()
+	dataDir := t.TempDir()
+
+	err := store.NewJSONFile(dataDir, "follows.json").Save([]models.Follow{{
+		ID:          models.FollowID("twitch", "xqc"),
+		Platform:    "twitch",
+		ChannelID:   "xqc",
+		DisplayName: "xQc",
+		AddedAt:     time.Now().UTC(),
+		Status: &models.Streamer{
+			ID:          "xqc",
+			Platform:    "twitch",
+			Username:    "xqc",
+			DisplayName: "xQc",
+			Title:       "last stream's title",
+			State:       models.StateOffline,
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeTwitch(t)
+	twitch := newFakeTwitchService(fake)
+
+	bus := NewEventBus()
+	events := make(chan models.StreamEvent, 8)
+	bus.Subscribe(func(e models.StreamEvent) { events <- e })
+	follows := NewFollowService(nil, nil, twitch, bus, dataDir, 0)
+
+	service := NewEventSubService(twitch, follows, "https://multistream.test/api/v1/eventsub/twitch", eventSubTestSecret, dataDir)
+	return &eventSubTest{service: service, follows: follows, twitch: fake, events: events}
+}
+
+// subscribed records verified stream.online and stream.offline
+// subscriptions for xqc, as a completed sync would
+func (e *eventSubTest) subscribed() {
+	e.service.mu.Lock()
+	defer e.service.mu.Unlock()
+	e.service.subs["xqc"] = &EventSubSubscription{
+		Login:           "xqc",
+		BroadcasterID:   eventSubTestBroadcaster,
+		SubscriptionIDs: map[string]string{"stream.online": "sub-online", "stream.offline": "sub-offline"},
+		Verified:        map[string]bool{"stream.online": true, "stream.offline": true},
+		CreatedAt:       time.Now().UTC(),
+	}
+}
+
+// send signs a message the way Twitch does and hands it to the service
+func (e *eventSubTest) send(messageType, messageID string, sent time.Time, body string) EventSubResult {
+	header := eventSubHeader(eventSubTestSecret, messageType, messageID, sent, body)
+	return e.service.Handle(context.Background(), header, []byte(body))
+}
+
+func (e *eventSubTest) waitEvent(t *testing.T) models.StreamEvent {
+	t.Helper()
+	select {
+	case event := <-e.events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no stream event published")
+	}
+	return models.StreamEvent{}
+}
+
+// waitSaved waits until the follow saved on disk is live or offline as
+// wanted, so a notification has finished writing before the test removes
+// the data directory
+func (e *eventSubTest) waitSaved(t *testing.T, live bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var follows []models.Follow
+		e.follows.file.Load(&follows)
+		if len(follows) == 1 && follows[0].IsLive() == live && follows[0].StatusChangedAt != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("follow never saved with live = %v", live)
+}
+
+func eventSubHeader(secret, messageType, messageID string, sent time.Time, body string) http.Header {
+	timestamp := sent.UTC().Format(time.RFC3339Nano)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(messageID + timestamp + body))
+
+	header := http.Header{}
+	header.Set(EventSubHeaderID, messageID)
+	header.Set(EventSubHeaderTimestamp, timestamp)
+	header.Set(EventSubHeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	header.Set(EventSubHeaderType, messageType)
+	return header
+}
+
+func eventSubVerificationBody(eventType, challenge string) string {
+	return fmt.Sprintf(`{
+		"challenge": %q,
+		"subscription": {"id": "sub-%s", "type": %q, "status": "webhook_callback_verification_pending",
+			"condition": {"broadcaster_user_id": %q}}
+	}`, challenge, eventType, eventType, eventSubTestBroadcaster)
+}
+
+func eventSubNotificationBody(eventType string) string {
+	return fmt.Sprintf(`{
+		"subscription": {"id": "sub-%s", "type": %q, "status": "enabled",
+			"condition": {"broadcaster_user_id": %q}},
+		"event": {"broadcaster_user_id": %q, "broadcaster_user_login": "xqc", "broadcaster_user_name": "xQc",
+			"type": "live", "started_at": "2026-01-01T18:00:00Z"}
+	}`, eventType, eventType, eventSubTestBroadcaster, eventSubTestBroadcaster)
+}
+
+func TestEventSubVerificationChallenge(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+	e.service.subs["xqc"].Verified = map[string]bool{}
+
+	follow, _ := e.follows.Get("twitch", "xqc")
+	if e.service.IsActive(*follow) {
+		t.Fatal("unverified subscription is active")
+	}
+
+	result := e.send(eventSubVerification, "msg-1", time.Now(), eventSubVerificationBody("stream.online", "pogchamp-kappa"))
+	if result.Status != http.StatusOK || result.Body != "pogchamp-kappa" || result.ContentType != "text/plain" {
+		t.Fatalf("verification = %+v, want the challenge echoed as text/plain", result)
+	}
+	if !e.service.IsActive(*follow) {
+		t.Error("verified stream.online subscription isn't active")
+	}
+}
+
+func TestEventSubRejectsBadSignatures(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+	body := eventSubNotificationBody("stream.offline")
+	now := time.Now()
+
+	cases := map[string]http.Header{
+		"wrong secret": eventSubHeader("other-secret", eventSubNotification, "msg-1", now, body),
+		"other body":   eventSubHeader(eventSubTestSecret, eventSubNotification, "msg-1", now, body+" "),
+		"no signature": func() http.Header {
+			h := eventSubHeader(eventSubTestSecret, eventSubNotification, "msg-1", now, body)
+			h.Del(EventSubHeaderSignature)
+			return h
+		}(),
+		"no prefix": func() http.Header {
+			h := eventSubHeader(eventSubTestSecret, eventSubNotification, "msg-1", now, body)
+			h.Set(EventSubHeaderSignature, h.Get(EventSubHeaderSignature)[len("sha256="):])
+			return h
+		}(),
+		// The ID and timestamp are signed too, so they can't be swapped
+		"other id": func() http.Header {
+			h := eventSubHeader(eventSubTestSecret, eventSubNotification, "msg-1", now, body)
+			h.Set(EventSubHeaderID, "msg-2")
+			return h
+		}(),
+		"other timestamp": func() http.Header {
+			h := eventSubHeader(eventSubTestSecret, eventSubNotification, "msg-1", now, body)
+			h.Set(EventSubHeaderTimestamp, now.Add(time.Second).UTC().Format(time.RFC3339Nano))
+			return h
+		}(),
+	}
+	for name, header := range cases {
+		if result := e.service.Handle(context.Background(), header, []byte(body)); result.Status != http.StatusForbidden {
+			t.Errorf("%s: status %d, want 403", name, result.Status)
+		}
+	}
+
+	select {
+	case event := <-e.events:
+		t.Errorf("rejected message published %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventSubRejectsStaleMessages(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+	body := eventSubVerificationBody("stream.online", "challenge")
+
+	for name, offset := range map[string]time.Duration{
+		"stale":  -eventSubMaxAge - time.Minute,
+		"future": eventSubMaxAge + time.Minute,
+	} {
+		if result := e.send(eventSubVerification, "msg-"+name, time.Now().Add(offset), body); result.Status != http.StatusForbidden {
+			t.Errorf("%s message: status %d, want 403", name, result.Status)
+		}
+	}
+
+	// Clocks a little apart are fine either way
+	for i, offset := range []time.Duration{-time.Minute, time.Minute} {
+		if result := e.send(eventSubVerification, fmt.Sprintf("msg-skew-%d", i), time.Now().Add(offset), body); result.Status != http.StatusOK {
+			t.Errorf("message %v from now: status %d, want 200", offset, result.Status)
+		}
+	}
+}
+
+func TestEventSubIgnoresDuplicates(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+	body := eventSubNotificationBody("stream.online")
+	sent := time.Now()
+
+	if result := e.send(eventSubNotification, "msg-1", sent, body); result.Status != http.StatusNoContent {
+		t.Fatalf("notification: status %d, want 204", result.Status)
+	}
+	e.waitEvent(t)
+	e.waitSaved(t, true)
+
+	// Twitch resends when unsure we got a message; it is acknowledged only
+	if result := e.send(eventSubNotification, "msg-1", sent, body); result.Status != http.StatusNoContent {
+		t.Errorf("duplicate: status %d, want 204", result.Status)
+	}
+	select {
+	case event := <-e.events:
+		t.Errorf("duplicate published %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEventSubNotificationUpdatesFollow(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+
+	// stream.online carries no title or viewers, so the stream is looked up
+	e.send(eventSubNotification, "msg-online", time.Now(), eventSubNotificationBody("stream.online"))
+	live := e.waitEvent(t)
+	if live.Type != models.EventWentLive || live.Streamer.Title != "helix title" || live.Streamer.ViewerCount != 52000 {
+		t.Errorf("online event = %+v, want went_live with the looked-up stream", live)
+	}
+
+	e.send(eventSubNotification, "msg-offline", time.Now(), eventSubNotificationBody("stream.offline"))
+	offline := e.waitEvent(t)
+	if offline.Type != models.EventWentOffline || offline.Streamer.IsLive {
+		t.Errorf("offline event = %+v, want went_offline", offline)
+	}
+	e.waitSaved(t, false)
+
+	e.service.mu.Lock()
+	lastEvent := e.service.subs["xqc"].LastEventAt
+	e.service.mu.Unlock()
+	if lastEvent == nil {
+		t.Error("LastEventAt not recorded")
+	}
+}
+
+func TestEventSubRevocation(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+
+	body := `{"subscription": {"id": "sub-online", "type": "stream.online", "status": "authorization_revoked",
+		"condition": {"broadcaster_user_id": "71092938"}}}`
+	if result := e.send(eventSubRevocation, "msg-1", time.Now(), body); result.Status != http.StatusNoContent {
+		t.Fatalf("revocation: status %d, want 204", result.Status)
+	}
+
+	sub := e.service.Subscriptions()[0]
+	if _, ok := sub.SubscriptionIDs["stream.online"]; ok || sub.Verified["stream.online"] {
+		t.Errorf("revoked subscription kept: %+v", sub)
+	}
+	if sub.SubscriptionIDs["stream.offline"] != "sub-offline" || !sub.Verified["stream.offline"] {
+		t.Errorf("other subscription lost: %+v", sub)
+	}
+
+	follow, _ := e.follows.Get("twitch", "xqc")
+	if e.service.IsActive(*follow) {
+		t.Error("follow still push-managed without a stream.online subscription")
+	}
+}
+
+func TestEventSubSync(t *testing.T) {
+	e := newEventSubTest(t)
+
+	e.service.Sync(context.Background())
+	sub := e.service.Subscriptions()[0]
+	if sub.BroadcasterID != eventSubTestBroadcaster || len(sub.SubscriptionIDs) != 2 {
+		t.Fatalf("synced subscription = %+v, want stream.online and stream.offline for %s", sub, eventSubTestBroadcaster)
+	}
+
+	// A second sync has nothing left to create
+	_, before := e.twitch.stats()
+	e.service.Sync(context.Background())
+	if _, after := e.twitch.stats(); len(after) != len(before) {
+		t.Errorf("second sync made Helix calls: %v", after[len(before):])
+	}
+}
+
+func TestEventSubSyncAdoptsExisting(t *testing.T) {
+	e := newEventSubTest(t)
+	e.service.Sync(context.Background())
+	created := e.service.Subscriptions()[0].SubscriptionIDs
+	e.twitch.set(func(f *fakeTwitch) { f.eventSubs[created["stream.online"]]["status"] = "enabled" })
+
+	// State lost since, so creating answers 409 Conflict
+	restarted := NewEventSubService(e.service.Twitch, e.follows, e.service.CallbackURL, eventSubTestSecret, t.TempDir())
+	restarted.Sync(context.Background())
+
+	sub := restarted.Subscriptions()[0]
+	if !maps.Equal(sub.SubscriptionIDs, created) {
+		t.Errorf("subscription IDs = %v, want the existing %v", sub.SubscriptionIDs, created)
+	}
+	if !sub.Verified["stream.online"] || sub.Verified["stream.offline"] {
+		t.Errorf("verified = %v, want only the enabled stream.online", sub.Verified)
+	}
+}
+
+func TestEventSubSyncKeepsFailedDeletes(t *testing.T) {
+	e := newEventSubTest(t)
+	e.service.Sync(context.Background())
+	e.follows.Remove("twitch", "xqc")
+
+	e.twitch.set(func(f *fakeTwitch) { f.deleteStatus = http.StatusServiceUnavailable })
+	e.service.Sync(context.Background())
+	if subs := e.service.Subscriptions(); len(subs) != 1 || len(subs[0].SubscriptionIDs) != 2 {
+		t.Fatalf("subscriptions after failed deletes = %+v, want them kept for a retry", subs)
+	}
+
+	e.twitch.set(func(f *fakeTwitch) { f.deleteStatus = 0 })
+	e.service.Sync(context.Background())
+	if subs := e.service.Subscriptions(); len(subs) != 0 {
+		t.Errorf("subscriptions after deleting = %+v, want none", subs)
+	}
+	e.twitch.set(func(f *fakeTwitch) {
+		if len(f.eventSubs) != 0 {
+			t.Errorf("Twitch still has %d subscriptions", len(f.eventSubs))
+		}
+	})
+}
+
+func TestEventSubOnlineWithoutStreamKeepsTitle(t *testing.T) {
+	e := newEventSubTest(t)
+	e.subscribed()
+	e.twitch.set(func(f *fakeTwitch) { f.helixStatus = http.StatusServiceUnavailable })
+
+	e.send(eventSubNotification, "msg-online", time.Now(), eventSubNotificationBody("stream.online"))
+	live := e.waitEvent(t)
+	if live.Type != models.EventWentLive || live.Streamer.Title != "last stream's title" {
+		t.Errorf("online event = %+v, want went_live with the last known title", live)
+	}
+	e.waitSaved(t, true)
+}
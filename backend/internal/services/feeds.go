@@ -0,0 +1,149 @@
+package services
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var feedsLog = logging.Component("feeds")
+
+// FeedService manages feed tokens and renders the subscribable feeds
+type FeedService struct {
+	Schedule    *ScheduleService
+	Follows     *FollowService
+	FrontendURL string
+
+	mu     sync.RWMutex
+	tokens map[string]*models.FeedToken
+	file   *store.JSONFile
+}
+
+// NewFeedService creates a new feed service backed by a file in dataDir
+func NewFeedService(schedule *ScheduleService, follows *FollowService, frontendURL, dataDir string) *FeedService {
+	s := &FeedService{
+		Schedule:    schedule,
+		Follows:     follows,
+		FrontendURL: frontendURL,
+		tokens:      make(map[string]*models.FeedToken),
+		file:        store.NewJSONFile(dataDir, "feed_tokens.json"),
+	}
+
+	var saved []models.FeedToken
+	if err := s.file.Load(&saved); err != nil {
+		feedsLog.Error("Failed to load feed tokens", "error", err)
+	}
+	for i := range saved {
+		s.tokens[saved[i].ID] = &saved[i]
+	}
+
+	return s
+}
+
+// CreateToken issues a new feed token. The token is only returned here.
+func (s *FeedService) CreateToken(req models.FeedTokenRequest) (*models.FeedToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	token := &models.FeedToken{
+		ID:        newID(),
+		Name:      name,
+		Token:     newID(),
+		Lists:     mergeLists(nil, req.Lists),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	s.tokens[token.ID] = token
+	s.mu.Unlock()
+
+	feedsLog.Info("Issued feed token", "id", token.ID, "name", name)
+	s.save()
+
+	created := *token
+	return &created, nil
+}
+
+// ListTokens returns all feed tokens with the tokens themselves hidden
+func (s *FeedService) ListTokens() []models.FeedToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]models.FeedToken, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		t := *token
+		t.Token = ""
+		tokens = append(tokens, t)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens
+}
+
+// DeleteToken revokes a feed token
+func (s *FeedService) DeleteToken(id string) bool {
+	s.mu.Lock()
+	_, ok := s.tokens[id]
+	delete(s.tokens, id)
+	s.mu.Unlock()
+
+	if ok {
+		s.save()
+	}
+	return ok
+}
+
+// Authenticate returns the feed token matching a presented secret
+func (s *FeedService) Authenticate(secret string) (*models.FeedToken, bool) {
+	if secret == "" {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Token), []byte(secret)) == 1 {
+			t := *token
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// FeedURLs returns the feed URLs a token unlocks, relative to the API's baseURL
+func (s *FeedService) FeedURLs(baseURL, secret string) map[string]string {
+	base := strings.TrimRight(baseURL, "/") + "/feeds/"
+	query := "?token=" + url.QueryEscape(secret)
+
+	return map[string]string{
+		"calendar": base + "schedule.ics" + query,
+		"atom":     base + "live.atom" + query,
+		"rss":      base + "live.rss" + query,
+	}
+}
+
+func (s *FeedService) save() {
+	s.mu.RLock()
+	tokens := make([]models.FeedToken, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, *token)
+	}
+	s.mu.RUnlock()
+
+	if err := s.file.Save(tokens); err != nil {
+		feedsLog.Error("Failed to save feed tokens", "error", err)
+	}
+}
@@ -0,0 +1,485 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+	"multistream/backend/internal/tracing"
+)
+
+var followsLog = logging.Component("follows")
+
+// ErrChannelNotFound is returned when a platform has no channel by the name
+// or ID looked up
+var ErrChannelNotFound = errors.New("channel not found")
+
+// maxConcurrentChecks limits how many channels are checked at once during a refresh
+const maxConcurrentChecks = 4
+
+// FollowService manages followed channels and keeps their live status current
+type FollowService struct {
+	YouTube  *YouTubeService
+	Kick     *KickService
+	Twitch   *TwitchService
+	Events   *EventBus
+	Interval time.Duration
+
+	mu          sync.RWMutex
+	pushSources []func(models.Follow) bool
+	watchers    []func(f models.Follow, followed bool)
+	follows     map[string]*models.Follow
+	file        *store.JSONFile
+}
+
+// NewFollowService creates a new follow service backed by a file in dataDir
+func NewFollowService(youtube *YouTubeService, kick *KickService, twitch *TwitchService, events *EventBus, dataDir string, interval time.Duration) *FollowService {
+	s := &FollowService{
+		YouTube:  youtube,
+		Kick:     kick,
+		Twitch:   twitch,
+		Events:   events,
+		Interval: interval,
+		follows:  make(map[string]*models.Follow),
+		file:     store.NewJSONFile(dataDir, "follows.json"),
+	}
+
+	var saved []models.Follow
+	if err := s.file.Load(&saved); err != nil {
+		followsLog.Error("Failed to load follows", "error", err)
+	}
+	for i := range saved {
+		s.follows[saved[i].ID] = &saved[i]
+	}
+
+	return s
+}
+
+// List returns all follows sorted by display name
+func (s *FollowService) List() []models.Follow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	follows := make([]models.Follow, 0, len(s.follows))
+	for _, f := range s.follows {
+		follows = append(follows, *f)
+	}
+
+	sort.Slice(follows, func(i, j int) bool {
+		return strings.ToLower(follows[i].DisplayName) < strings.ToLower(follows[j].DisplayName)
+	})
+	return follows
+}
+
+// Live returns the follows that are currently live, sorted by viewers
+func (s *FollowService) Live() []models.Follow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	follows := make([]models.Follow, 0)
+	for _, f := range s.follows {
+		if f.IsLive() {
+			follows = append(follows, *f)
+		}
+	}
+
+	sort.Slice(follows, func(i, j int) bool {
+		return follows[i].Status.ViewerCount > follows[j].Status.ViewerCount
+	})
+	return follows
+}
+
+// Get returns a single follow
+func (s *FollowService) Get(platform, channelID string) (*models.Follow, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.follows[models.FollowID(platform, channelID)]
+	if !ok {
+		return nil, false
+	}
+	follow := *f
+	return &follow, true
+}
+
+// Add resolves a channel on its platform and follows it. Following a channel
+// that is already followed adds it to the given lists.
+func (s *FollowService) Add(ctx context.Context, platform, channel string, lists []string) (*models.Follow, error) {
+	channel = strings.TrimSpace(channel)
+	if channel == "" {
+		return nil, fmt.Errorf("channel is required")
+	}
+
+	var resolved *models.Streamer
+	var err error
+
+	switch platform {
+	case "youtube":
+		resolved, err = s.YouTube.GetChannel(ctx, channel)
+	case "kick":
+		resolved, err = s.Kick.GetChannelInfo(ctx, channel)
+	case "twitch":
+		resolved, err = s.Twitch.GetChannelInfo(ctx, channel)
+	default:
+		return nil, fmt.Errorf("invalid platform: must be youtube, kick, or twitch")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Kick identifies channels by slug, Twitch by login, YouTube by channel ID
+	channelID := resolved.Username
+	id := models.FollowID(platform, channelID)
+
+	s.mu.Lock()
+	if existing, ok := s.follows[id]; ok {
+		existing.Lists = mergeLists(existing.Lists, lists)
+		follow := *existing
+		s.mu.Unlock()
+		s.changed(id)
+		s.save()
+		return &follow, nil
+	}
+
+	follow := &models.Follow{
+		ID:          id,
+		Platform:    platform,
+		ChannelID:   channelID,
+		DisplayName: resolved.DisplayName,
+		Lists:       mergeLists(nil, lists),
+		AddedAt:     time.Now().UTC(),
+	}
+	s.follows[id] = follow
+	s.mu.Unlock()
+
+	followsLog.InfoContext(ctx, "Following", "follow", id)
+
+	// Fetch the initial status right away so the follow is useful immediately
+	s.refresh(ctx, *follow)
+	s.save()
+
+	f, _ := s.Get(platform, channelID)
+	return f, nil
+}
+
+// SetLists replaces the follow lists a follow belongs to
+func (s *FollowService) SetLists(platform, channelID string, lists []string) (*models.Follow, bool) {
+	s.mu.Lock()
+	f, ok := s.follows[models.FollowID(platform, channelID)]
+	if !ok {
+		s.mu.Unlock()
+		return nil, false
+	}
+	f.Lists = mergeLists(nil, lists)
+	follow := *f
+	s.mu.Unlock()
+
+	s.changed(follow.ID)
+	s.save()
+	return &follow, true
+}
+
+// LiveStreams returns the live streams of follows in any of the given lists,
+// sorted by viewers
+func (s *FollowService) LiveStreams(lists []string) []models.Streamer {
+	streams := make([]models.Streamer, 0)
+	for _, f := range s.Live() {
+		if f.InList(lists) {
+			streams = append(streams, *f.Status)
+		}
+	}
+	return streams
+}
+
+// Remove unfollows a channel
+func (s *FollowService) Remove(platform, channelID string) bool {
+	id := models.FollowID(platform, channelID)
+
+	s.mu.Lock()
+	f, ok := s.follows[id]
+	delete(s.follows, id)
+	watchers := s.watchers
+	s.mu.Unlock()
+
+	if ok {
+		followsLog.Info("Unfollowed", "follow", id)
+		for _, watch := range watchers {
+			watch(*f, false)
+		}
+		s.save()
+	}
+	return ok
+}
+
+// AddWatcher registers a function told about every follow that is added or
+// changes, and with followed false about every one that is removed
+func (s *FollowService) AddWatcher(watch func(f models.Follow, followed bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers = append(s.watchers, watch)
+}
+
+// changed tells the watchers about the current state of a follow
+func (s *FollowService) changed(id string) {
+	s.mu.RLock()
+	f, ok := s.follows[id]
+	var follow models.Follow
+	if ok {
+		follow = *f
+	}
+	watchers := s.watchers
+	s.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+	for _, watch := range watchers {
+		watch(follow, true)
+	}
+}
+
+// AddPushSource registers a check reporting whether go-live for a follow is
+// pushed to us. Polling is skipped for such follows while they are offline.
+func (s *FollowService) AddPushSource(pushed func(models.Follow) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushSources = append(s.pushSources, pushed)
+}
+
+// PushManaged reports whether any push source covers a follow
+func (s *FollowService) PushManaged(f models.Follow) bool {
+	s.mu.RLock()
+	sources := s.pushSources
+	s.mu.RUnlock()
+
+	for _, pushed := range sources {
+		if pushed(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run refreshes the live status of all follows every Interval until ctx is done
+func (s *FollowService) Run(ctx context.Context) {
+	if s.Interval <= 0 {
+		followsLog.Info("Background refresh disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	s.RefreshAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RefreshAll(ctx)
+		}
+	}
+}
+
+// RefreshAll checks the live status of every follow
+func (s *FollowService) RefreshAll(ctx context.Context) {
+	follows := s.List()
+	if len(follows) == 0 {
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, "follows.refresh", attribute.Int("follows.count", len(follows)))
+	defer span.End()
+
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+
+	for _, f := range follows {
+		if !f.IsLive() && s.PushManaged(f) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f models.Follow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.refresh(ctx, f)
+		}(f)
+	}
+
+	wg.Wait()
+	s.save()
+}
+
+// refresh checks the live status of a single follow and records it
+func (s *FollowService) refresh(ctx context.Context, f models.Follow) {
+	status, err := s.checkStatus(ctx, f)
+	if err != nil {
+		followsLog.WarnContext(ctx, "Failed to check follow", "follow", f.ID, "error", err)
+	}
+	for _, event := range s.applyStatus(f.ID, status, err) {
+		s.Events.Publish(event)
+	}
+	s.changed(f.ID)
+}
+
+// UpdateStatus records a status pushed to us for a followed channel
+func (s *FollowService) UpdateStatus(platform, channelID string, status *models.Streamer) {
+	id := models.FollowID(platform, channelID)
+	for _, event := range s.applyStatus(id, status, nil) {
+		s.Events.Publish(event)
+	}
+	s.changed(id)
+	s.save()
+}
+
+// checkStatus asks the provider for the current status of a followed channel
+func (s *FollowService) checkStatus(ctx context.Context, f models.Follow) (*models.Streamer, error) {
+	switch f.Platform {
+	case "youtube":
+		pushManaged := s.PushManaged(f)
+
+		// Checking the known broadcast costs far less quota than a channel search
+		if f.IsLive() {
+			video, err := s.YouTube.GetStreamInfo(ctx, f.Status.ID)
+			switch {
+			case err == nil && video.IsLive:
+				return video, nil
+			case err != nil && pushManaged:
+				return nil, err
+			}
+		}
+
+		offline := &models.Streamer{
+			ID:          f.ChannelID,
+			Platform:    "youtube",
+			Username:    f.ChannelID,
+			DisplayName: f.DisplayName,
+			IsLive:      false,
+			State:       models.StateOffline,
+		}
+
+		// New broadcasts on push-managed channels are announced to us
+		if pushManaged {
+			return offline, nil
+		}
+
+		live, err := s.YouTube.GetLiveStream(ctx, f.ChannelID)
+		if err != nil || live != nil {
+			return live, err
+		}
+		return offline, nil
+	case "kick":
+		return s.Kick.GetChannelInfo(ctx, f.ChannelID)
+	case "twitch":
+		return s.Twitch.GetChannelInfo(ctx, f.ChannelID)
+	default:
+		return nil, fmt.Errorf("unsupported platform: %s", f.Platform)
+	}
+}
+
+// applyStatus records a status check result on a follow and returns the
+// events caused by the change
+func (s *FollowService) applyStatus(id string, status *models.Streamer, checkErr error) []models.StreamEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.follows[id]
+	if !ok {
+		// Unfollowed while the check was in flight
+		return nil
+	}
+
+	now := time.Now().UTC()
+	f.LastChecked = &now
+
+	if checkErr != nil {
+		f.LastError = checkErr.Error()
+		return nil
+	}
+
+	previous := f.Status
+	wasLive := f.IsLive()
+	f.Status = status
+	f.LastError = ""
+
+	if status.DisplayName != "" {
+		f.DisplayName = status.DisplayName
+	}
+
+	switch {
+	case status.IsLive && !wasLive:
+		f.LiveSince = &now
+	case !status.IsLive:
+		f.LiveSince = nil
+	}
+
+	if status.IsLive != wasLive || (status.IsLive && (previous == nil || status.Title != previous.Title)) {
+		f.StatusChangedAt = &now
+	}
+
+	// The first check after following only establishes the baseline
+	if previous == nil {
+		return nil
+	}
+
+	var events []models.StreamEvent
+	newEvent := func(eventType string) models.StreamEvent {
+		return models.StreamEvent{
+			ID:         newID(),
+			Type:       eventType,
+			FollowID:   f.ID,
+			Platform:   f.Platform,
+			ChannelID:  f.ChannelID,
+			Lists:      f.Lists,
+			Streamer:   *status,
+			OccurredAt: now,
+		}
+	}
+
+	switch {
+	case status.IsLive && !wasLive:
+		events = append(events, newEvent(models.EventWentLive))
+	case !status.IsLive && wasLive:
+		events = append(events, newEvent(models.EventWentOffline))
+	case status.IsLive && status.Title != previous.Title:
+		event := newEvent(models.EventTitleChanged)
+		event.PreviousTitle = previous.Title
+		events = append(events, event)
+	}
+
+	for _, event := range events {
+		followsLog.Info("Stream event", "follow", f.ID, "type", event.Type)
+	}
+	return events
+}
+
+// mergeLists adds lists to existing, dropping blanks and duplicates
+func mergeLists(existing, lists []string) []string {
+	merged := append([]string(nil), existing...)
+	for _, list := range lists {
+		list = strings.TrimSpace(list)
+		if list != "" && !slices.Contains(merged, list) {
+			merged = append(merged, list)
+		}
+	}
+	return merged
+}
+
+func (s *FollowService) save() {
+	if err := s.file.Save(s.List()); err != nil {
+		followsLog.Error("Failed to save follows", "error", err)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/models"
+)
+
+// healthCheckTimeout is how long a readiness probe waits for a check
+const healthCheckTimeout = 5 * time.Second
+
+// Health states, from best to worst
+const (
+	HealthHealthy   = "healthy"
+	HealthDegraded  = "degraded"
+	HealthUnhealthy = "unhealthy"
+)
+
+// HealthCheck checks one dependency and returns its state with a short note,
+// which says what is wrong unless it is healthy. ctx is cancelled when the
+// check runs out of time.
+type HealthCheck func(ctx context.Context) (status, message string)
+
+// HealthService checks the dependencies of the backend. Results are cached
+// for TTL so frequent probes don't turn into upstream traffic.
+type HealthService struct {
+	TTL time.Duration
+	// Resilience, when set, marks a dependency unhealthy while the circuit
+	// breaker of the same name is open
+	Resilience *Resilience
+
+	dependencies []*healthDependency
+}
+
+type healthDependency struct {
+	name     string
+	critical bool
+	check    HealthCheck
+
+	// mu is held while checking, so concurrent probes share one check
+	mu     sync.Mutex
+	result models.DependencyHealth
+}
+
+// NewHealthService creates a health service caching check results for ttl
+func NewHealthService(ttl time.Duration) *HealthService {
+	return &HealthService{TTL: ttl}
+}
+
+// Register adds a dependency. The service is unhealthy when a critical
+// dependency is, and degraded when any other one isn't healthy.
+func (h *HealthService) Register(name string, critical bool, check HealthCheck) {
+	h.dependencies = append(h.dependencies, &healthDependency{name: name, critical: critical, check: check})
+}
+
+// Ready checks every dependency, reusing results younger than TTL
+func (h *HealthService) Ready() models.HealthResponse {
+	checks := make([]models.DependencyHealth, len(h.dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range h.dependencies {
+		wg.Add(1)
+		go func(i int, dep *healthDependency) {
+			defer wg.Done()
+			checks[i] = h.checkDependency(dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	response := models.HealthResponse{
+		Status:   HealthHealthy,
+		Checks:   checks,
+		Breakers: []models.BreakerState{},
+	}
+	for _, check := range checks {
+		switch {
+		case check.Status == HealthUnhealthy && check.Critical:
+			response.Status = HealthUnhealthy
+		case check.Status != HealthHealthy && response.Status == HealthHealthy:
+			response.Status = HealthDegraded
+		}
+	}
+	if h.Resilience != nil {
+		response.Breakers = h.Resilience.Breakers()
+	}
+	return response
+}
+
+func (h *HealthService) checkDependency(dep *healthDependency) models.DependencyHealth {
+	dep.mu.Lock()
+	defer dep.mu.Unlock()
+
+	if !dep.result.CheckedAt.IsZero() && time.Since(dep.result.CheckedAt) < h.TTL {
+		return dep.result
+	}
+
+	// The result is shared by every probe, so no probe's context is used
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	type outcome struct{ status, message string }
+	done := make(chan outcome, 1)
+	started := time.Now()
+	go func() {
+		status, message := dep.check(ctx)
+		done <- outcome{status, message}
+	}()
+
+	var result outcome
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = outcome{HealthUnhealthy, fmt.Sprintf("check timed out after %s", healthCheckTimeout)}
+	}
+
+	if h.Resilience != nil {
+		if breaker, ok := h.Resilience.Breaker(dep.name); ok {
+			switch breaker.State {
+			case breakerOpen:
+				result = outcome{HealthUnhealthy, "circuit breaker open: " + breaker.LastError}
+			case breakerHalfOpen:
+				if result.status == HealthHealthy {
+					result = outcome{HealthDegraded, "circuit breaker half-open"}
+				}
+			}
+		}
+	}
+
+	dep.result = models.DependencyHealth{
+		Name:      dep.name,
+		Status:    result.status,
+		Critical:  dep.critical,
+		Message:   result.message,
+		LatencyMs: time.Since(started).Milliseconds(),
+		CheckedAt: time.Now().UTC(),
+	}
+	return dep.result
+}
+
+// StorageHealth checks that files can be written to the data directory
+func StorageHealth(dataDir string) HealthCheck {
+	return func(context.Context) (string, string) {
+		f, err := os.CreateTemp(dataDir, ".health-*")
+		if err != nil {
+			return HealthUnhealthy, fmt.Sprintf("data directory not writable: %v", err)
+		}
+		f.Close()
+		os.Remove(f.Name())
+		return HealthHealthy, ""
+	}
+}
+
+// CacheHealth reports the size of the in-memory leaderboard and category
+// caches, which can't fail independently of the process
+func CacheHealth(top *TopService, categories *CategoryService) HealthCheck {
+	return func(context.Context) (string, string) {
+		return HealthHealthy, fmt.Sprintf("%d leaderboards, %d category stream lists cached",
+			top.CacheSize(), categories.CacheSize())
+	}
+}
@@ -1,23 +1,69 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"multistream/backend/internal/logging"
 	"multistream/backend/internal/models"
+	"multistream/backend/internal/tracing"
 )
 
+var kickLog = logging.Component("kick")
+
+const (
+	// maxSlugCandidates caps how many slugs a fallback lookup probes
+	maxSlugCandidates = 8
+
+	// kickProbeConcurrency limits how many slugs are probed at once
+	kickProbeConcurrency = 4
+)
+
+// kickSlugNoise are words people add to a channel name that its slug
+// usually leaves out
+var kickSlugNoise = map[string]bool{
+	"official": true,
+	"channel":  true,
+	"live":     true,
+	"stream":   true,
+	"streams":  true,
+	"gaming":   true,
+	"kick":     true,
+	"tv":       true,
+	"ttv":      true,
+	"yt":       true,
+}
+
 // KickService handles Kick API interactions
-// Note: Official Kick API requires OAuth. We use the unofficial v2 API for search.
+// Note: Kick's public API (see Official) has no channel search or category
+// directory, so those always use the unofficial v2 API.
 type KickService struct {
 	BaseURL string
 	Client  *http.Client
+	// Official, when set, serves channels and livestreams from Kick's public
+	// API, falling back to the unofficial one when it fails
+	Official *KickOfficialClient
+	// Observer, when set, is told about every channel and stream returned
+	Observer StreamObserver
+	// Channels, when set, offers known slugs close to a misspelled query
+	Channels *ChannelIndex
+
+	searchHealth *endpointHealth
+
+	// seenCategories collects the recent categories of searched channels
+	mu             sync.Mutex
+	seenCategories map[string]KickCategory
 }
 
 // NewKickService creates a new Kick service
@@ -27,29 +73,85 @@ func NewKickService() *KickService {
 		Client: &http.Client{
 			Timeout: 15 * time.Second,
 		},
+		seenCategories: make(map[string]KickCategory),
+		searchHealth:   newEndpointHealth(DefaultKickSearchEndpoints, 5*time.Minute),
 	}
 }
 
+// ConfigureSearch replaces the search endpoints, tried healthiest first, and
+// how long one sits out after failing repeatedly. {query} in an endpoint is
+// replaced with the escaped search query.
+func (s *KickService) ConfigureSearch(endpoints []string, cooldown time.Duration) {
+	if len(endpoints) == 0 {
+		endpoints = DefaultKickSearchEndpoints
+	}
+	s.searchHealth = newEndpointHealth(endpoints, cooldown)
+}
+
+// SearchEndpointHealth returns what has been learned about each search endpoint
+func (s *KickService) SearchEndpointHealth() []models.EndpointHealth {
+	return s.searchHealth.snapshot()
+}
+
+// CheckHealth asks Kick for one category, which fails when Kick is down or
+// blocking us, and checks that some search endpoint is usable
+func (s *KickService) CheckHealth(ctx context.Context) (string, string) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://kick.com/api/v1/subcategories?page=1&limit=1", nil)
+	if err != nil {
+		return HealthUnhealthy, err.Error()
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return HealthUnhealthy, err.Error()
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return HealthUnhealthy, fmt.Sprintf("Kick answered with status %d", resp.StatusCode)
+	}
+
+	if len(s.searchHealth.order()) == 0 {
+		return HealthDegraded, "all search endpoints are cooling down, searches use direct lookup"
+	}
+	if s.Official != nil {
+		if _, err := s.Official.appToken(ctx, false); err != nil {
+			return HealthDegraded, "public API unavailable, using the unofficial API: " + err.Error()
+		}
+	}
+	return HealthHealthy, ""
+}
+
+// KickCategory is a Kick category (subcategory in Kick's API)
+type KickCategory struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	Viewers int    `json:"viewers"`
+	Banner  struct {
+		URL string `json:"url"`
+		Src string `json:"src"`
+	} `json:"banner"`
+}
+
 // KickSearchResponse represents the v2 search API response
 type KickSearchResponse struct {
 	Channels []KickSearchChannel `json:"channels"`
 }
 
 type KickSearchChannel struct {
-	ID               int    `json:"id"`
-	Username         string `json:"username"`
-	Slug             string `json:"slug"`
-	ProfilePic       string `json:"profile_pic"`
-	IsLive           bool   `json:"is_live"`
-	IsBanned         bool   `json:"is_banned"`
-	ViewerCount      int    `json:"viewer_count"`
-	FollowersCount   int    `json:"followers_count"`
-	VerifiedChannel  bool   `json:"verified"`
-	RecentCategories []struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
-		Slug string `json:"slug"`
-	} `json:"recent_categories"`
+	ID               int            `json:"id"`
+	Username         string         `json:"username"`
+	Slug             string         `json:"slug"`
+	ProfilePic       string         `json:"profile_pic"`
+	IsLive           bool           `json:"is_live"`
+	IsBanned         bool           `json:"is_banned"`
+	ViewerCount      int            `json:"viewer_count"`
+	FollowersCount   int            `json:"followers_count"`
+	VerifiedChannel  bool           `json:"verified"`
+	RecentCategories []KickCategory `json:"recent_categories"`
 }
 
 // KickChannelResponse represents the v2 channel API response (for direct lookup)
@@ -65,6 +167,8 @@ type KickChannelResponse struct {
 		SessionTitle string `json:"session_title"`
 		IsLive       bool   `json:"is_live"`
 		ViewerCount  int    `json:"viewer_count"`
+		IsMature     bool   `json:"is_mature"`
+		StartTime    string `json:"start_time"`
 		Thumbnail    struct {
 			URL string `json:"url"`
 		} `json:"thumbnail"`
@@ -75,65 +179,283 @@ type KickChannelResponse struct {
 	Verified bool `json:"verified"`
 }
 
-// SearchChannels searches for channels on Kick
-func (s *KickService) SearchChannels(query string, maxResults int) ([]models.Streamer, error) {
-	log.Printf("[Kick] ========== SEARCH DEBUG ==========")
-	log.Printf("[Kick] Raw query received: '%s'", query)
+// KickLivestream is a stream from Kick's livestream directory
+type KickLivestream struct {
+	ID           int    `json:"id"`
+	Slug         string `json:"slug"`
+	SessionTitle string `json:"session_title"`
+	IsLive       bool   `json:"is_live"`
+	ViewerCount  int    `json:"viewer_count"`
+	Language     string `json:"language"`
+	IsMature     bool   `json:"is_mature"`
+	StartTime    string `json:"start_time"`
+	Thumbnail    struct {
+		Src string `json:"src"`
+		URL string `json:"url"`
+	} `json:"thumbnail"`
+	Categories []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"categories"`
+	Channel struct {
+		ID   int    `json:"id"`
+		Slug string `json:"slug"`
+		User struct {
+			Username   string `json:"username"`
+			ProfilePic string `json:"profilepic"`
+		} `json:"user"`
+	} `json:"channel"`
+}
+
+// GetLivestreams gets the most watched live streams from Kick's directory,
+// optionally in a category (by slug). Kick lists livestreams per language,
+// so the English directory is used when no language is given.
+func (s *KickService) GetLivestreams(ctx context.Context, category, language string, limit int) (_ []models.Streamer, err error) {
+	ctx, span := tracing.Start(ctx, "kick.livestreams", attribute.String("kick.category", category))
+	defer tracing.End(span, &err)
+
+	if s.Official != nil {
+		streamers, err := s.officialLivestreams(ctx, category, language, limit)
+		if err == nil {
+			s.Observer.notify(streamers...)
+			return streamers, nil
+		}
+		kickLog.WarnContext(ctx, "Public API livestreams failed, using unofficial API", "error", err)
+	}
 
-	// Try multiple API endpoints
-	endpoints := []string{
-		fmt.Sprintf("https://kick.com/api/v2/search/channels?query=%s", url.QueryEscape(query)),
-		fmt.Sprintf("https://kick.com/api/v1/search?query=%s", url.QueryEscape(query)),
-		fmt.Sprintf("https://kick.com/api/search?query=%s", url.QueryEscape(query)),
+	if language == "" {
+		language = "en"
 	}
 
-	for _, searchURL := range endpoints {
-		log.Printf("[Kick] Trying: %s", searchURL)
+	query := url.Values{
+		"page":  {"1"},
+		"limit": {fmt.Sprintf("%d", limit)},
+		"sort":  {"desc"},
+	}
+	if category != "" {
+		query.Set("subcategory", category)
+	}
+	directoryURL := fmt.Sprintf("https://kick.com/stream/livestreams/%s?%s", url.PathEscape(language), query.Encode())
 
-		req, err := http.NewRequest("GET", searchURL, nil)
-		if err != nil {
-			continue
+	req, err := http.NewRequestWithContext(ctx, "GET", directoryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Origin", "https://kick.com")
+	req.Header.Set("Referer", "https://kick.com/browse")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get livestreams: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		kickLog.WarnContext(ctx, "Livestreams error", "status", resp.StatusCode)
+		kickLog.DebugContext(ctx, "Livestreams response", "status", resp.StatusCode, "body", logging.Body(body))
+		return nil, fmt.Errorf("Kick API error: status %d", resp.StatusCode)
+	}
+
+	var directory struct {
+		Data []KickLivestream `json:"data"`
+	}
+	if err := json.Unmarshal(body, &directory); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	streamers := make([]models.Streamer, 0, len(directory.Data))
+	for _, ls := range directory.Data {
+		slug := ls.Channel.Slug
+		if slug == "" {
+			slug = strings.ToLower(ls.Channel.User.Username)
 		}
 
-		// Set headers to mimic browser
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-		req.Header.Set("Accept", "application/json, text/plain, */*")
-		req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-		req.Header.Set("Origin", "https://kick.com")
-		req.Header.Set("Referer", "https://kick.com/search?query="+url.QueryEscape(query))
+		thumbnail := ls.Thumbnail.Src
+		if thumbnail == "" {
+			thumbnail = ls.Thumbnail.URL
+		}
 
-		resp, err := s.Client.Do(req)
+		streamer := models.Streamer{
+			ID:              fmt.Sprintf("%d", ls.Channel.ID),
+			Platform:        "kick",
+			Username:        slug,
+			DisplayName:     ls.Channel.User.Username,
+			Title:           ls.SessionTitle,
+			Thumbnail:       thumbnail,
+			ViewerCount:     ls.ViewerCount,
+			Language:        kickLanguageCode(ls.Language),
+			Mature:          ls.IsMature,
+			IsLive:          true,
+			State:           models.StateLive,
+			ActualStartTime: parseKickTime(ls.StartTime),
+			EmbedURL:        fmt.Sprintf("https://player.kick.com/%s", slug),
+			ChatURL:         fmt.Sprintf("https://kick.com/%s/chatroom", slug),
+		}
+		if len(ls.Categories) > 0 {
+			streamer.Category = ls.Categories[0].Name
+		}
+		streamers = append(streamers, streamer)
+	}
+
+	s.Observer.notify(streamers...)
+	return streamers, nil
+}
+
+// officialLivestreams gets livestreams from the public API, which filters by
+// category ID rather than slug
+func (s *KickService) officialLivestreams(ctx context.Context, category, language string, limit int) ([]models.Streamer, error) {
+	categoryID := 0
+	if category != "" {
+		var err error
+		if categoryID, err = s.Official.CategoryID(ctx, category); err != nil {
+			return nil, err
+		}
+	}
+	return s.Official.GetLivestreams(ctx, categoryID, language, limit)
+}
+
+// kickLanguages maps the language names Kick reports to ISO 639-1 codes
+var kickLanguages = map[string]string{
+	"arabic":     "ar",
+	"chinese":    "zh",
+	"english":    "en",
+	"french":     "fr",
+	"german":     "de",
+	"italian":    "it",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"polish":     "pl",
+	"portuguese": "pt",
+	"russian":    "ru",
+	"spanish":    "es",
+	"turkish":    "tr",
+}
+
+// kickLanguageCode normalizes a Kick language name, leaving unknown ones as is
+func kickLanguageCode(language string) string {
+	if code, ok := kickLanguages[strings.ToLower(language)]; ok {
+		return code
+	}
+	return language
+}
+
+// SearchChannels searches for channels on Kick
+func (s *KickService) SearchChannels(ctx context.Context, query string, maxResults int) ([]models.Streamer, error) {
+	streamers, _, err := s.SearchChannelsFrom(ctx, query, 0, maxResults)
+	return streamers, err
+}
+
+// SearchChannelsFrom returns up to limit channels of a search starting at
+// offset, and whether more follow. Kick's search answers with every match at
+// once, so pages are cut from that list.
+func (s *KickService) SearchChannelsFrom(ctx context.Context, query string, offset, limit int) (_ []models.Streamer, _ bool, err error) {
+	ctx, span := tracing.Start(ctx, "kick.search", attribute.Int("kick.offset", offset))
+	defer tracing.End(span, &err)
+
+	channels, err := s.searchChannels(ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	if channels == nil {
+		if offset > 0 {
+			return []models.Streamer{}, false, nil
+		}
+		// Fallback: try direct channel lookup
+		kickLog.InfoContext(ctx, "All search endpoints failed, trying direct channel lookup")
+		streamers, err := s.fallbackDirectLookup(ctx, query)
+		return streamers, false, err
+	}
+
+	if offset >= len(channels) {
+		return []models.Streamer{}, false, nil
+	}
+	end := min(offset+limit, len(channels))
+	return s.convertChannels(channels[offset:end], limit), end < len(channels), nil
+}
+
+// searchChannels returns every channel Kick's search finds for query, or nil
+// when no search endpoint answered. It fails only while Kick's circuit
+// breaker is open.
+func (s *KickService) searchChannels(ctx context.Context, query string) ([]KickSearchChannel, error) {
+	for _, endpoint := range s.searchHealth.order() {
+		searchURL := strings.ReplaceAll(endpoint, "{query}", url.QueryEscape(query))
+		kickLog.DebugContext(ctx, "Trying search endpoint", "url", searchURL)
+
+		endpointCtx, span := tracing.Start(ctx, "kick.search_endpoint", attribute.String("kick.endpoint", endpoint))
+		channels, err := s.trySearch(endpointCtx, searchURL, query)
+		tracing.End(span, &err)
+		if errors.Is(err, ErrProviderUnavailable) {
+			// Kick as a whole is down; the endpoint is not to blame
+			return nil, err
+		}
+		s.searchHealth.record(endpoint, err)
 		if err != nil {
-			log.Printf("[Kick] HTTP error for %s: %v", searchURL, err)
+			kickLog.WarnContext(ctx, "Search endpoint failed", "endpoint", endpoint, "error", err)
 			continue
 		}
+		if len(channels) > 0 {
+			return channels, nil
+		}
+	}
 
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+	return nil, nil
+}
 
-		log.Printf("[Kick] Response from %s: status=%d, body=%s", searchURL, resp.StatusCode, truncateString(string(body), 300))
+// trySearch runs a search on one endpoint. Any answer that isn't a list of
+// channels is an error, so the endpoint's health reflects it.
+func (s *KickService) trySearch(ctx context.Context, searchURL, query string) ([]KickSearchChannel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 
-		if resp.StatusCode == http.StatusOK && len(body) > 10 {
-			// Try to parse as channel array directly
-			var channels []KickSearchChannel
-			if err := json.Unmarshal(body, &channels); err == nil && len(channels) > 0 {
-				return s.convertChannels(channels, maxResults), nil
-			}
+	// Set headers to mimic browser
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Origin", "https://kick.com")
+	req.Header.Set("Referer", "https://kick.com/search?query="+url.QueryEscape(query))
 
-			// Try to parse as search response with channels field
-			var searchResp KickSearchResponse
-			if err := json.Unmarshal(body, &searchResp); err == nil && len(searchResp.Channels) > 0 {
-				return s.convertChannels(searchResp.Channels, maxResults), nil
-			}
-		}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Fallback: try direct channel lookup
-	log.Printf("[Kick] All search endpoints failed, trying direct channel lookup")
-	return s.fallbackDirectLookup(query)
+	kickLog.DebugContext(ctx, "Search response", "url", searchURL, "status", resp.StatusCode, "body", logging.Body(body))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	// Try to parse as channel array directly
+	var channels []KickSearchChannel
+	if err := json.Unmarshal(body, &channels); err == nil {
+		return channels, nil
+	}
+
+	// Try to parse as search response with channels field
+	var searchResp KickSearchResponse
+	if err := json.Unmarshal(body, &searchResp); err != nil {
+		return nil, errors.New("unexpected response shape")
+	}
+	return searchResp.Channels, nil
 }
 
 func (s *KickService) convertChannels(channels []KickSearchChannel, maxResults int) []models.Streamer {
+	s.rememberCategories(channels)
+
 	streamers := make([]models.Streamer, 0, len(channels))
 	for i, ch := range channels {
 		if i >= maxResults {
@@ -150,6 +472,11 @@ func (s *KickService) convertChannels(channels []KickSearchChannel, maxResults i
 			slug = strings.ToLower(ch.Username)
 		}
 
+		category := ""
+		if len(ch.RecentCategories) > 0 {
+			category = ch.RecentCategories[0].Name
+		}
+
 		streamers = append(streamers, models.Streamer{
 			ID:          fmt.Sprintf("%d", ch.ID),
 			Platform:    "kick",
@@ -158,45 +485,217 @@ func (s *KickService) convertChannels(channels []KickSearchChannel, maxResults i
 			Title:       title,
 			Thumbnail:   ch.ProfilePic,
 			ViewerCount: ch.ViewerCount,
+			Category:    category,
+			Verified:    ch.VerifiedChannel,
 			IsLive:      ch.IsLive,
+			State:       models.LiveState(ch.IsLive),
 			EmbedURL:    fmt.Sprintf("https://player.kick.com/%s", slug),
 			ChatURL:     fmt.Sprintf("https://kick.com/%s/chatroom", slug),
 		})
 	}
+
+	s.Observer.notify(streamers...)
 	return streamers
 }
 
-// fallbackDirectLookup tries to find a channel by direct slug lookup
-func (s *KickService) fallbackDirectLookup(query string) ([]models.Streamer, error) {
-	log.Printf("[Kick] Trying fallback: direct channel lookup for '%s'", query)
+// rememberCategories records every recent category of searched channels, so
+// categories can be found without listing all of them
+func (s *KickService) rememberCategories(channels []KickSearchChannel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ch := range channels {
+		for _, category := range ch.RecentCategories {
+			if category.Slug != "" {
+				s.seenCategories[category.Slug] = category
+			}
+		}
+	}
+}
+
+// GetCategories gets Kick's categories, most watched first, together with
+// categories seen on searched channels
+func (s *KickService) GetCategories(ctx context.Context, limit int) (_ []KickCategory, err error) {
+	ctx, span := tracing.Start(ctx, "kick.categories")
+	defer tracing.End(span, &err)
 
-	cleanQuery := strings.ToLower(strings.TrimSpace(query))
-	cleanQuery = strings.ReplaceAll(cleanQuery, " ", "")
+	categoriesURL := fmt.Sprintf("https://kick.com/api/v1/subcategories?page=1&limit=%d", limit)
 
-	channel, err := s.GetChannelInfo(cleanQuery)
+	req, err := http.NewRequestWithContext(ctx, "GET", categoriesURL, nil)
 	if err != nil {
-		log.Printf("[Kick] Fallback failed: %v", err)
-		return []models.Streamer{}, nil
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return []models.Streamer{*channel}, nil
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Origin", "https://kick.com")
+	req.Header.Set("Referer", "https://kick.com/categories")
+
+	var listed []KickCategory
+	resp, err := s.Client.Do(req)
+	if err == nil {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		var page struct {
+			Data []KickCategory `json:"data"`
+		}
+		switch {
+		case resp.StatusCode != http.StatusOK:
+			err = fmt.Errorf("Kick API error: status %d", resp.StatusCode)
+		case json.Unmarshal(body, &page) != nil:
+			err = fmt.Errorf("failed to decode categories")
+		default:
+			listed = page.Data
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil && len(s.seenCategories) == 0 {
+		return nil, err
+	}
+	if err != nil {
+		kickLog.WarnContext(ctx, "Failed to list categories, using ones seen in search", "error", err)
+	}
+
+	categories := listed
+	listedSlugs := make(map[string]bool, len(listed))
+	for _, category := range listed {
+		listedSlugs[category.Slug] = true
+	}
+	for slug, category := range s.seenCategories {
+		if !listedSlugs[slug] {
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+// fallbackDirectLookup looks up the channels whose slugs the query most
+// likely spells, and returns all of them that exist
+func (s *KickService) fallbackDirectLookup(ctx context.Context, query string) ([]models.Streamer, error) {
+	candidates := s.slugCandidates(query)
+	ctx, span := tracing.Start(ctx, "kick.fallback_lookup", attribute.StringSlice("kick.candidates", candidates))
+	defer span.End()
+	kickLog.InfoContext(ctx, "Trying direct channel lookup", "query", query, "candidates", candidates)
+
+	found := make([]*models.Streamer, len(candidates))
+	sem := make(chan struct{}, kickProbeConcurrency)
+	var wg sync.WaitGroup
+
+	for i, slug := range candidates {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, slug string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			channel, err := s.GetChannelInfo(ctx, slug)
+			if err != nil {
+				return
+			}
+			found[i] = channel
+		}(i, slug)
+	}
+	wg.Wait()
+
+	// Keep the candidates' order, and each channel once
+	streamers := make([]models.Streamer, 0)
+	seen := make(map[string]bool)
+	for _, channel := range found {
+		if channel == nil || seen[channel.Username] {
+			continue
+		}
+		seen[channel.Username] = true
+		streamers = append(streamers, *channel)
+	}
+
+	if len(streamers) == 0 {
+		kickLog.InfoContext(ctx, "Direct channel lookup found no channel", "query", query)
+	}
+	return streamers, nil
+}
+
+// slugCandidates turns a search query into the channel slugs it most likely
+// means, best first: the words joined as they are, with underscores and with
+// hyphens, then the same without words like "official" or "tv", then known
+// Kick channels a typo or two away
+func (s *KickService) slugCandidates(query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if i := strings.Index(query, "kick.com/"); i >= 0 {
+		query = strings.SplitN(query[i+len("kick.com/"):], "/", 2)[0]
+	}
+	query = strings.TrimPrefix(query, "@")
+
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-'
+	})
+
+	var core []string
+	for _, word := range words {
+		if !kickSlugNoise[word] {
+			core = append(core, word)
+		}
+	}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(slug string) {
+		if slug != "" && !seen[slug] && len(candidates) < maxSlugCandidates {
+			seen[slug] = true
+			candidates = append(candidates, slug)
+		}
+	}
+
+	for _, set := range [][]string{words, core} {
+		add(strings.Join(set, ""))
+		add(strings.Join(set, "_"))
+		add(strings.Join(set, "-"))
+	}
+
+	if s.Channels != nil {
+		for _, set := range [][]string{core, words} {
+			for _, slug := range s.Channels.Similar("kick", strings.Join(set, ""), maxSlugCandidates) {
+				add(slug)
+			}
+		}
+	}
+	return candidates
 }
 
 // SearchLiveStreams searches for live streams on Kick
-func (s *KickService) SearchLiveStreams(query string, maxResults int) ([]models.Streamer, error) {
-	return s.SearchChannels(query, maxResults)
+func (s *KickService) SearchLiveStreams(ctx context.Context, query string, maxResults int) ([]models.Streamer, error) {
+	return s.SearchChannels(ctx, query, maxResults)
 }
 
 // GetChannelInfo gets detailed info for a specific channel by slug
-func (s *KickService) GetChannelInfo(channelSlug string) (*models.Streamer, error) {
+func (s *KickService) GetChannelInfo(ctx context.Context, channelSlug string) (_ *models.Streamer, err error) {
 	cleanSlug := strings.ToLower(strings.TrimSpace(channelSlug))
 	cleanSlug = strings.ReplaceAll(cleanSlug, " ", "")
 
+	ctx, span := tracing.Start(ctx, "kick.channel", attribute.String("kick.slug", cleanSlug))
+	defer tracing.End(span, &err)
+
+	if s.Official != nil {
+		streamer, err := s.Official.GetChannelInfo(ctx, cleanSlug)
+		switch {
+		case err == nil:
+			s.Observer.notify(*streamer)
+			return streamer, nil
+		case errors.Is(err, ErrKickChannelNotFound):
+			return nil, err
+		}
+		kickLog.WarnContext(ctx, "Public API failed for channel, using unofficial API", "slug", cleanSlug, "error", err)
+	}
+
 	// Try v2 channels endpoint
 	channelURL := fmt.Sprintf("https://kick.com/api/v2/channels/%s", url.PathEscape(cleanSlug))
-	log.Printf("[Kick] Fetching channel: %s", channelURL)
+	kickLog.DebugContext(ctx, "Fetching channel", "url", channelURL)
 
-	req, err := http.NewRequest("GET", channelURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", channelURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -209,25 +708,26 @@ func (s *KickService) GetChannelInfo(channelSlug string) (*models.Streamer, erro
 
 	resp, err := s.Client.Do(req)
 	if err != nil {
-		log.Printf("[Kick] HTTP error: %v", err)
 		return nil, fmt.Errorf("failed to get channel info: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	log.Printf("[Kick] Channel response (status %d): %s", resp.StatusCode, truncateString(string(body), 300))
+	kickLog.DebugContext(ctx, "Channel response", "slug", cleanSlug, "status", resp.StatusCode, "body", logging.Body(body))
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, cleanSlug)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("channel not found: %s (status %d)", cleanSlug, resp.StatusCode)
+		return nil, fmt.Errorf("Kick API error: status %d", resp.StatusCode)
 	}
 
 	var channelResp KickChannelResponse
 	if err := json.Unmarshal(body, &channelResp); err != nil {
-		log.Printf("[Kick] JSON decode error: %v", err)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	log.Printf("[Kick] Successfully fetched channel: %s (ID: %d)", channelResp.User.Username, channelResp.ID)
+	kickLog.DebugContext(ctx, "Fetched channel", "slug", channelResp.Slug, "id", channelResp.ID)
 
 	streamer := &models.Streamer{
 		ID:          fmt.Sprintf("%d", channelResp.ID),
@@ -235,7 +735,9 @@ func (s *KickService) GetChannelInfo(channelSlug string) (*models.Streamer, erro
 		Username:    channelResp.Slug,
 		DisplayName: channelResp.User.Username,
 		Thumbnail:   channelResp.User.ProfilePic,
+		Verified:    channelResp.Verified,
 		IsLive:      false,
+		State:       models.StateOffline,
 		EmbedURL:    fmt.Sprintf("https://player.kick.com/%s", channelResp.Slug),
 		ChatURL:     fmt.Sprintf("https://kick.com/%s/chatroom", channelResp.Slug),
 	}
@@ -244,6 +746,11 @@ func (s *KickService) GetChannelInfo(channelSlug string) (*models.Streamer, erro
 		streamer.Title = channelResp.Livestream.SessionTitle
 		streamer.ViewerCount = channelResp.Livestream.ViewerCount
 		streamer.IsLive = channelResp.Livestream.IsLive
+		streamer.Mature = channelResp.Livestream.IsMature
+		streamer.State = models.LiveState(streamer.IsLive)
+		if streamer.IsLive {
+			streamer.ActualStartTime = parseKickTime(channelResp.Livestream.StartTime)
+		}
 		if channelResp.Livestream.Thumbnail.URL != "" {
 			streamer.Thumbnail = channelResp.Livestream.Thumbnail.URL
 		}
@@ -257,13 +764,19 @@ func (s *KickService) GetChannelInfo(channelSlug string) (*models.Streamer, erro
 		streamer.Title = channelResp.User.Username
 	}
 
+	s.Observer.notify(*streamer)
 	return streamer, nil
 }
 
-// truncateString helper
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// parseKickTime parses a Kick timestamp, which the v2 API sends either as
+// RFC 3339 or as a bare UTC "2006-01-02 15:04:05"
+func parseKickTime(value string) *time.Time {
+	if t := parseTime(value); t != nil {
+		return t
+	}
+	t, err := time.Parse(time.DateTime, value)
+	if err != nil {
+		return nil
 	}
-	return s[:maxLen] + "..."
+	return &t
 }
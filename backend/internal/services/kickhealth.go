@@ -0,0 +1,121 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/models"
+)
+
+const (
+	// endpointHealthWeight is how much the latest attempt moves an
+	// endpoint's health score
+	endpointHealthWeight = 0.3
+
+	// endpointFailureThreshold is how many failures in a row put an endpoint
+	// in cooldown
+	endpointFailureThreshold = 3
+
+	// maxEndpointCooldown caps how long an endpoint sits out after repeated
+	// failures
+	maxEndpointCooldown = time.Hour
+)
+
+// DefaultKickSearchEndpoints are the search endpoints tried when none are
+// configured. {query} is replaced with the escaped search query.
+var DefaultKickSearchEndpoints = []string{
+	"https://kick.com/api/v2/search/channels?query={query}",
+	"https://kick.com/api/v1/search?query={query}",
+	"https://kick.com/api/search?query={query}",
+}
+
+// endpointHealth learns which of several interchangeable endpoints work.
+// Each endpoint has a score that moves toward 1 on success and 0 on
+// failure; after endpointFailureThreshold failures in a row an endpoint is
+// skipped for a cooldown that doubles with every further failure.
+type endpointHealth struct {
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	endpoints []*models.EndpointHealth
+}
+
+func newEndpointHealth(endpoints []string, cooldown time.Duration) *endpointHealth {
+	h := &endpointHealth{cooldown: cooldown}
+	for _, endpoint := range endpoints {
+		h.endpoints = append(h.endpoints, &models.EndpointHealth{Endpoint: endpoint, Score: 1})
+	}
+	return h
+}
+
+// order returns the endpoints not in cooldown, healthiest first, keeping
+// the configured order between equally healthy ones
+func (h *endpointHealth) order() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	ready := make([]*models.EndpointHealth, 0, len(h.endpoints))
+	for _, e := range h.endpoints {
+		if e.CooldownUntil == nil || now.After(*e.CooldownUntil) {
+			ready = append(ready, e)
+		}
+	}
+	sort.SliceStable(ready, func(i, j int) bool {
+		return ready[i].Score > ready[j].Score
+	})
+
+	endpoints := make([]string, 0, len(ready))
+	for _, e := range ready {
+		endpoints = append(endpoints, e.Endpoint)
+	}
+	return endpoints
+}
+
+// record notes the outcome of an attempt on an endpoint; err is nil on success
+func (h *endpointHealth) record(endpoint string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range h.endpoints {
+		if e.Endpoint != endpoint {
+			continue
+		}
+
+		now := time.Now().UTC()
+		if err == nil {
+			e.Successes++
+			e.ConsecutiveFailures = 0
+			e.LastSuccess = &now
+			e.CooldownUntil = nil
+			e.Score += endpointHealthWeight * (1 - e.Score)
+			return
+		}
+
+		e.Failures++
+		e.ConsecutiveFailures++
+		e.LastFailure = &now
+		e.LastError = err.Error()
+		e.Score -= endpointHealthWeight * e.Score
+
+		if e.ConsecutiveFailures >= endpointFailureThreshold && h.cooldown > 0 {
+			cooldown := h.cooldown << min(e.ConsecutiveFailures-endpointFailureThreshold, 10)
+			until := now.Add(min(cooldown, maxEndpointCooldown))
+			e.CooldownUntil = &until
+		}
+		return
+	}
+}
+
+// snapshot returns the learned state of every endpoint in configured order
+func (h *endpointHealth) snapshot() []models.EndpointHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	endpoints := make([]models.EndpointHealth, 0, len(h.endpoints))
+	for _, e := range h.endpoints {
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/tracing"
+)
+
+// Kick providers selectable per deployment
+const (
+	KickProviderUnofficial = "unofficial"
+	KickProviderOfficial   = "official"
+)
+
+// ErrKickChannelNotFound is returned when the official API knows no channel
+// by a slug
+var ErrKickChannelNotFound = fmt.Errorf("Kick %w", ErrChannelNotFound)
+
+// KickOfficialClient talks to Kick's public API with an app access token
+// from the client-credentials flow. The public API has no channel search or
+// category directory, so KickService keeps the unofficial endpoints for those.
+type KickOfficialClient struct {
+	ClientID     string
+	ClientSecret string
+	BaseURL      string
+	AuthURL      string
+	Client       *http.Client
+
+	// tokenMu guards the token and is held while fetching one, so
+	// concurrent callers share a fetch without blocking other state
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+
+	mu sync.Mutex
+	// categoryIDs maps category slugs to the numeric IDs the API filters by
+	categoryIDs map[string]int
+}
+
+// NewKickOfficialClient creates a client for Kick's public API
+func NewKickOfficialClient(clientID, clientSecret string) *KickOfficialClient {
+	return &KickOfficialClient{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		BaseURL:      "https://api.kick.com/public/v1",
+		AuthURL:      "https://id.kick.com/oauth/token",
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		categoryIDs: make(map[string]int),
+	}
+}
+
+// Configured reports whether Kick API credentials are set
+func (c *KickOfficialClient) Configured() bool {
+	return c.ClientID != "" && c.ClientSecret != ""
+}
+
+// KickAPIError is returned when the public API answers with a non-2xx status
+type KickAPIError struct {
+	StatusCode int
+}
+
+func (e *KickAPIError) Error() string {
+	return fmt.Sprintf("Kick API error: status %d", e.StatusCode)
+}
+
+// KickOfficialCategory is a category from the public API
+type KickOfficialCategory struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+// KickOfficialChannel is a channel from the public channels API
+type KickOfficialChannel struct {
+	BroadcasterUserID int                  `json:"broadcaster_user_id"`
+	Slug              string               `json:"slug"`
+	BannerPicture     string               `json:"banner_picture"`
+	StreamTitle       string               `json:"stream_title"`
+	Category          KickOfficialCategory `json:"category"`
+	Stream            struct {
+		IsLive      bool   `json:"is_live"`
+		IsMature    bool   `json:"is_mature"`
+		Language    string `json:"language"`
+		StartTime   string `json:"start_time"`
+		ViewerCount int    `json:"viewer_count"`
+		Thumbnail   string `json:"thumbnail"`
+	} `json:"stream"`
+}
+
+// KickOfficialUser is a user from the public users API
+type KickOfficialUser struct {
+	UserID         int    `json:"user_id"`
+	Name           string `json:"name"`
+	ProfilePicture string `json:"profile_picture"`
+}
+
+// KickOfficialLivestream is a stream from the public livestreams API
+type KickOfficialLivestream struct {
+	BroadcasterUserID int                  `json:"broadcaster_user_id"`
+	ChannelID         int                  `json:"channel_id"`
+	Slug              string               `json:"slug"`
+	StreamTitle       string               `json:"stream_title"`
+	Language          string               `json:"language"`
+	HasMatureContent  bool                 `json:"has_mature_content"`
+	ViewerCount       int                  `json:"viewer_count"`
+	Thumbnail         string               `json:"thumbnail"`
+	ProfilePicture    string               `json:"profile_picture"`
+	StartedAt         string               `json:"started_at"`
+	Category          KickOfficialCategory `json:"category"`
+}
+
+// GetChannelInfo gets the current status of a channel by slug
+func (c *KickOfficialClient) GetChannelInfo(ctx context.Context, slug string) (*models.Streamer, error) {
+	var resp struct {
+		Data []KickOfficialChannel `json:"data"`
+	}
+	if err := c.get(ctx, "/channels", url.Values{"slug": {slug}}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKickChannelNotFound, slug)
+	}
+	ch := resp.Data[0]
+
+	streamer := &models.Streamer{
+		ID:          strconv.Itoa(ch.BroadcasterUserID),
+		Platform:    "kick",
+		Username:    ch.Slug,
+		DisplayName: ch.Slug,
+		Title:       ch.StreamTitle,
+		Thumbnail:   ch.BannerPicture,
+		Category:    ch.Category.Name,
+		ViewerCount: ch.Stream.ViewerCount,
+		Language:    kickLanguageCode(ch.Stream.Language),
+		Mature:      ch.Stream.IsMature,
+		IsLive:      ch.Stream.IsLive,
+		State:       models.LiveState(ch.Stream.IsLive),
+		EmbedURL:    fmt.Sprintf("https://player.kick.com/%s", ch.Slug),
+		ChatURL:     fmt.Sprintf("https://kick.com/%s/chatroom", ch.Slug),
+	}
+
+	// The channel only has the slug; the user has the name and picture
+	if user, err := c.getUser(ctx, ch.BroadcasterUserID); err == nil {
+		streamer.DisplayName = user.Name
+		streamer.Thumbnail = user.ProfilePicture
+	} else {
+		kickLog.WarnContext(ctx, "Failed to get channel user from the public API", "slug", ch.Slug, "error", err)
+	}
+
+	if streamer.IsLive {
+		streamer.ActualStartTime = parseKickTime(ch.Stream.StartTime)
+		if ch.Stream.Thumbnail != "" {
+			streamer.Thumbnail = ch.Stream.Thumbnail
+		}
+	} else {
+		streamer.ViewerCount = 0
+	}
+	if streamer.Title == "" {
+		streamer.Title = streamer.DisplayName
+	}
+	return streamer, nil
+}
+
+func (c *KickOfficialClient) getUser(ctx context.Context, id int) (*KickOfficialUser, error) {
+	var resp struct {
+		Data []KickOfficialUser `json:"data"`
+	}
+	if err := c.get(ctx, "/users", url.Values{"id": {strconv.Itoa(id)}}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("user not found: %d", id)
+	}
+	return &resp.Data[0], nil
+}
+
+// GetLivestreams gets the most watched live streams, optionally in a
+// category (by ID, 0 for all) and language
+func (c *KickOfficialClient) GetLivestreams(ctx context.Context, categoryID int, language string, limit int) ([]models.Streamer, error) {
+	query := url.Values{
+		"limit": {strconv.Itoa(min(max(limit, 1), 100))},
+		"sort":  {"viewer_count"},
+	}
+	if categoryID > 0 {
+		query.Set("category_id", strconv.Itoa(categoryID))
+	}
+	if language != "" {
+		query.Set("language", language)
+	}
+
+	var resp struct {
+		Data []KickOfficialLivestream `json:"data"`
+	}
+	if err := c.get(ctx, "/livestreams", query, &resp); err != nil {
+		return nil, err
+	}
+
+	streamers := make([]models.Streamer, 0, len(resp.Data))
+	for _, ls := range resp.Data {
+		thumbnail := ls.Thumbnail
+		if thumbnail == "" {
+			thumbnail = ls.ProfilePicture
+		}
+		id := ls.ChannelID
+		if id == 0 {
+			id = ls.BroadcasterUserID
+		}
+		streamers = append(streamers, models.Streamer{
+			ID:              strconv.Itoa(id),
+			Platform:        "kick",
+			Username:        ls.Slug,
+			DisplayName:     ls.Slug,
+			Title:           ls.StreamTitle,
+			Thumbnail:       thumbnail,
+			Category:        ls.Category.Name,
+			ViewerCount:     ls.ViewerCount,
+			Language:        kickLanguageCode(ls.Language),
+			Mature:          ls.HasMatureContent,
+			IsLive:          true,
+			State:           models.StateLive,
+			ActualStartTime: parseKickTime(ls.StartedAt),
+			EmbedURL:        fmt.Sprintf("https://player.kick.com/%s", ls.Slug),
+			ChatURL:         fmt.Sprintf("https://kick.com/%s/chatroom", ls.Slug),
+		})
+	}
+	return streamers, nil
+}
+
+// CategoryID resolves a category slug to its ID by searching categories
+// for its words. Resolved IDs are remembered.
+func (c *KickOfficialClient) CategoryID(ctx context.Context, slug string) (int, error) {
+	c.mu.Lock()
+	id, ok := c.categoryIDs[slug]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var resp struct {
+		Data []KickOfficialCategory `json:"data"`
+	}
+	query := url.Values{"q": {strings.ReplaceAll(slug, "-", " ")}}
+	if err := c.get(ctx, "/categories", query, &resp); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, category := range resp.Data {
+		c.categoryIDs[categoryID(category.Name)] = category.ID
+	}
+	if id, ok := c.categoryIDs[slug]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("category not found: %s", slug)
+}
+
+func (c *KickOfficialClient) get(ctx context.Context, path string, query url.Values, out interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "kick.official."+strings.TrimPrefix(path, "/"))
+	defer tracing.End(span, &err)
+
+	if !c.Configured() {
+		return fmt.Errorf("Kick API credentials not configured")
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		token, err := c.appToken(ctx, attempt > 0)
+		if err != nil {
+			return err
+		}
+
+		requestURL := c.BaseURL + path
+		if len(query) > 0 {
+			requestURL += "?" + query.Encode()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to call Kick: %w", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			kickLog.InfoContext(ctx, "App token rejected, refreshing")
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			kickLog.WarnContext(ctx, "Public API error", "path", path, "status", resp.StatusCode)
+			kickLog.DebugContext(ctx, "Public API response", "path", path, "status", resp.StatusCode, "body", logging.Body(body))
+			return &KickAPIError{StatusCode: resp.StatusCode}
+		}
+
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Kick API error: unauthorized")
+}
+
+// appToken returns a cached app access token, fetching a new one when it is
+// missing, about to expire, or force is set
+func (c *KickOfficialClient) appToken(ctx context.Context, force bool) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if !force && c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.AuthURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Kick app token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get Kick app token: status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	c.token = tokenResp.AccessToken
+	// Refresh a minute early so requests in flight don't race the expiry
+	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKick stands in for Kick: the OAuth token endpoint, the public API
+// under /public/v1 and the unofficial endpoints the service falls back to
+type fakeKick struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// issued counts the app tokens handed out; only the latest is accepted
+	issued int
+	// revoked rejects the latest token until a new one is issued
+	revoked bool
+	// publicStatus, when set, fails every public API call with it
+	publicStatus int
+	// authorized lists the token each public API call carried
+	authorized []string
+	// unofficial counts calls to the unofficial endpoints
+	unofficial int
+	// tokenGate, when set, is sent on as a token request arrives and then
+	// holds it until received from
+	tokenGate chan struct{}
+}
+
+func newFakeKick(t *testing.T) *fakeKick {
+	t.Helper()
+	k := &fakeKick{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/token", k.token)
+	mux.HandleFunc("GET /public/v1/", k.public)
+	mux.HandleFunc("GET /api/v2/channels/{slug}", k.unofficialChannel)
+	mux.HandleFunc("GET /stream/livestreams/{language}", k.unofficialLivestreams)
+	k.Server = httptest.NewServer(mux)
+	t.Cleanup(k.Close)
+	return k
+}
+
+func (k *fakeKick) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.PostForm.Get("grant_type") != "client_credentials" ||
+		r.PostForm.Get("client_id") != "client-id" || r.PostForm.Get("client_secret") != "client-secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	k.mu.Lock()
+	gate := k.tokenGate
+	k.mu.Unlock()
+	if gate != nil {
+		gate <- struct{}{}
+		<-gate
+	}
+
+	k.mu.Lock()
+	k.issued++
+	k.revoked = false
+	token := fmt.Sprintf("token-%d", k.issued)
+	k.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (k *fakeKick) public(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	k.mu.Lock()
+	k.authorized = append(k.authorized, token)
+	valid := token == fmt.Sprintf("token-%d", k.issued) && !k.revoked
+	status := k.publicStatus
+	k.mu.Unlock()
+
+	switch {
+	case !valid:
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case status != 0:
+		w.WriteHeader(status)
+		return
+	}
+
+	query := r.URL.Query()
+	var data []map[string]interface{}
+	switch strings.TrimPrefix(r.URL.Path, "/public/v1") {
+	case "/channels":
+		if query.Get("slug") == "xqc" {
+			data = append(data, map[string]interface{}{
+				"broadcaster_user_id": 676,
+				"slug":                "xqc",
+				"stream_title":        "official title",
+				"category":            map[string]interface{}{"id": 15, "name": "Just Chatting"},
+				"stream": map[string]interface{}{
+					"is_live":      true,
+					"language":     "English",
+					"start_time":   "2026-01-01T00:00:00Z",
+					"viewer_count": 30000,
+					"thumbnail":    "https://files.kick.test/xqc.webp",
+				},
+			})
+		}
+	case "/users":
+		data = append(data, map[string]interface{}{
+			"user_id":         676,
+			"name":            "xQc",
+			"profile_picture": "https://files.kick.test/xqc-avatar.webp",
+		})
+	case "/categories":
+		data = append(data, map[string]interface{}{"id": 15, "name": "Just Chatting"})
+	case "/livestreams":
+		if query.Get("category_id") != "" && query.Get("category_id") != "15" {
+			break
+		}
+		data = append(data, map[string]interface{}{
+			"broadcaster_user_id": 676,
+			"channel_id":          668,
+			"slug":                "xqc",
+			"stream_title":        "official title",
+			"language":            "English",
+			"viewer_count":        30000,
+			"category":            map[string]interface{}{"id": 15, "name": "Just Chatting"},
+		})
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "message": "OK"})
+}
+
+func (k *fakeKick) unofficialChannel(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	k.unofficial++
+	k.mu.Unlock()
+
+	fmt.Fprintf(w, `{
+		"id": 668, "slug": %q, "verified": true,
+		"user": {"username": "xQc", "profile_pic": "https://files.kick.test/xqc-avatar.webp"},
+		"livestream": {"id": 1, "session_title": "unofficial title", "is_live": true, "viewer_count": 29000}
+	}`, r.PathValue("slug"))
+}
+
+func (k *fakeKick) unofficialLivestreams(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	k.unofficial++
+	k.mu.Unlock()
+
+	fmt.Fprint(w, `{"data": [{
+		"id": 1, "session_title": "unofficial title", "is_live": true, "viewer_count": 29000, "language": "English",
+		"channel": {"id": 668, "slug": "xqc", "user": {"username": "xQc"}}
+	}]}`)
+}
+
+func (k *fakeKick) stats() (issued int, authorized []string, unofficial int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.issued, append([]string(nil), k.authorized...), k.unofficial
+}
+
+func (k *fakeKick) set(fn func(k *fakeKick)) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	fn(k)
+}
+
+// redirectTransport sends every request to target, keeping the path, so the
+// hardcoded kick.com URLs reach the fake
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newFakeKickClient(k *fakeKick) *KickOfficialClient {
+	c := NewKickOfficialClient("client-id", "client-secret")
+	c.BaseURL = k.URL + "/public/v1"
+	c.AuthURL = k.URL + "/oauth/token"
+	return c
+}
+
+func newFakeKickService(k *fakeKick) *KickService {
+	s := NewKickService()
+	target, _ := url.Parse(k.URL)
+	s.Client = &http.Client{Transport: redirectTransport{target: target}, Timeout: 5 * time.Second}
+	s.Official = newFakeKickClient(k)
+	return s
+}
+
+func TestKickOfficialChannelInfo(t *testing.T) {
+	k := newFakeKick(t)
+	c := newFakeKickClient(k)
+
+	streamer, err := c.GetChannelInfo(context.Background(), "xqc")
+	if err != nil {
+		t.Fatalf("GetChannelInfo: %v", err)
+	}
+	if streamer.ID != "676" || streamer.DisplayName != "xQc" || !streamer.IsLive || streamer.ViewerCount != 30000 {
+		t.Errorf("streamer = %+v", streamer)
+	}
+	if streamer.Thumbnail != "https://files.kick.test/xqc.webp" || streamer.Category != "Just Chatting" {
+		t.Errorf("thumbnail %q, category %q", streamer.Thumbnail, streamer.Category)
+	}
+	// Kick names languages; streams carry ISO 639-1 codes everywhere else
+	if streamer.Language != "en" {
+		t.Errorf("language = %q, want en", streamer.Language)
+	}
+
+	if _, err := c.GetChannelInfo(context.Background(), "nobody"); !errors.Is(err, ErrKickChannelNotFound) {
+		t.Errorf("unknown slug = %v, want %v", err, ErrKickChannelNotFound)
+	}
+}
+
+func TestKickOfficialCachesToken(t *testing.T) {
+	k := newFakeKick(t)
+	c := newFakeKickClient(k)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetChannelInfo(context.Background(), "xqc"); err != nil {
+			t.Fatalf("GetChannelInfo: %v", err)
+		}
+	}
+	if _, err := c.GetLivestreams(context.Background(), 0, "", 10); err != nil {
+		t.Fatalf("GetLivestreams: %v", err)
+	}
+
+	issued, authorized, _ := k.stats()
+	if issued != 1 {
+		t.Errorf("issued %d tokens, want 1", issued)
+	}
+	for _, token := range authorized {
+		if token != "token-1" {
+			t.Errorf("request authorized with %q, want token-1", token)
+		}
+	}
+}
+
+func TestKickOfficialRefreshesToken(t *testing.T) {
+	k := newFakeKick(t)
+	c := newFakeKickClient(k)
+	ctx := context.Background()
+
+	if _, err := c.GetChannelInfo(ctx, "xqc"); err != nil {
+		t.Fatalf("GetChannelInfo: %v", err)
+	}
+
+	// A token Kick stops accepting is replaced and the call retried
+	k.set(func(k *fakeKick) { k.revoked = true })
+	if _, err := c.GetLivestreams(ctx, 0, "", 10); err != nil {
+		t.Fatalf("GetLivestreams with a revoked token: %v", err)
+	}
+	if issued, _, _ := k.stats(); issued != 2 {
+		t.Errorf("issued %d tokens after a rejection, want 2", issued)
+	}
+
+	// A token near expiry is replaced before it is used
+	c.tokenMu.Lock()
+	c.tokenExpiry = time.Now().Add(-time.Second)
+	c.tokenMu.Unlock()
+	if _, err := c.GetLivestreams(ctx, 0, "", 10); err != nil {
+		t.Fatalf("GetLivestreams with an expired token: %v", err)
+	}
+
+	issued, authorized, _ := k.stats()
+	if issued != 3 {
+		t.Errorf("issued %d tokens after expiry, want 3", issued)
+	}
+	if last := authorized[len(authorized)-1]; last != "token-3" {
+		t.Errorf("last request authorized with %q, want token-3", last)
+	}
+}
+
+func TestKickOfficialCategoryID(t *testing.T) {
+	k := newFakeKick(t)
+	c := newFakeKickClient(k)
+
+	for i := 0; i < 2; i++ {
+		id, err := c.CategoryID(context.Background(), "just-chatting")
+		if err != nil || id != 15 {
+			t.Fatalf("CategoryID = %d, %v, want 15", id, err)
+		}
+	}
+	if _, authorized, _ := k.stats(); len(authorized) != 1 {
+		t.Errorf("made %d category lookups, want 1", len(authorized))
+	}
+}
+
+func TestKickOfficialTokenFetchDoesntBlockCache(t *testing.T) {
+	k := newFakeKick(t)
+	c := newFakeKickClient(k)
+	ctx := context.Background()
+
+	if _, err := c.CategoryID(ctx, "just-chatting"); err != nil {
+		t.Fatalf("CategoryID: %v", err)
+	}
+
+	gate := make(chan struct{})
+	k.set(func(k *fakeKick) { k.tokenGate = gate })
+	go c.appToken(ctx, true)
+	<-gate // the token request is in flight
+	defer func() { gate <- struct{}{} }()
+
+	done := make(chan struct{})
+	go func() {
+		c.CategoryID(ctx, "just-chatting")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("cached category lookup waited for the token fetch")
+	}
+}
+
+func TestKickServiceUsesOfficialAPI(t *testing.T) {
+	k := newFakeKick(t)
+	s := newFakeKickService(k)
+	ctx := context.Background()
+
+	streamer, err := s.GetChannelInfo(ctx, "xqc")
+	if err != nil || streamer.Title != "official title" {
+		t.Fatalf("GetChannelInfo = %+v, %v, want the public API's channel", streamer, err)
+	}
+	streams, err := s.GetLivestreams(ctx, "just-chatting", "", 10)
+	if err != nil || len(streams) != 1 || streams[0].Title != "official title" || streams[0].Language != "en" {
+		t.Fatalf("GetLivestreams = %+v, %v, want the public API's streams", streams, err)
+	}
+	if _, err := s.GetChannelInfo(ctx, "nobody"); !errors.Is(err, ErrKickChannelNotFound) {
+		t.Errorf("unknown slug = %v, want %v", err, ErrKickChannelNotFound)
+	}
+
+	if _, _, unofficial := k.stats(); unofficial != 0 {
+		t.Errorf("made %d unofficial calls, want none", unofficial)
+	}
+}
+
+func TestKickServiceFallsBackToUnofficial(t *testing.T) {
+	k := newFakeKick(t)
+	s := newFakeKickService(k)
+	ctx := context.Background()
+
+	k.set(func(k *fakeKick) { k.publicStatus = http.StatusServiceUnavailable })
+
+	streamer, err := s.GetChannelInfo(ctx, "xqc")
+	if err != nil {
+		t.Fatalf("GetChannelInfo: %v", err)
+	}
+	if streamer.Title != "unofficial title" || !streamer.Verified || streamer.ViewerCount != 29000 {
+		t.Errorf("streamer = %+v, want the unofficial API's channel", streamer)
+	}
+
+	streams, err := s.GetLivestreams(ctx, "", "", 10)
+	if err != nil || len(streams) != 1 || streams[0].Title != "unofficial title" {
+		t.Fatalf("GetLivestreams = %+v, %v, want the unofficial API's streams", streams, err)
+	}
+
+	// Bad credentials fail at the token endpoint, and fall back the same way
+	s.Official.ClientSecret = "wrong"
+	s.Official.tokenExpiry = time.Time{}
+	if _, err := s.GetChannelInfo(ctx, "xqc"); err != nil {
+		t.Fatalf("GetChannelInfo without a token: %v", err)
+	}
+
+	if _, _, unofficial := k.stats(); unofficial != 3 {
+		t.Errorf("made %d unofficial calls, want 3", unofficial)
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"multistream/backend/internal/models"
+)
+
+// Atom (RFC 4287) document types
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Author    atomAuthor   `xml:"author"`
+	Category  atomCategory `xml:"category"`
+	Links     []atomLink   `xml:"link"`
+	Summary   string       `xml:"summary"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// RSS 2.0 document types
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	TTL           int       `xml:"ttl"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description"`
+	Category    string        `xml:"category"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// liveEntry is a live follow as it appears in the live feeds
+type liveEntry struct {
+	ID        string
+	Title     string
+	Summary   string
+	Streamer  models.Streamer
+	WatchURL  string
+	Published time.Time
+	Updated   time.Time
+}
+
+// LiveAtom renders the live follows visible to a feed token as an Atom feed
+func (s *FeedService) LiveAtom(token models.FeedToken, selfURL string) ([]byte, error) {
+	entries, updated := s.liveEntries(token)
+
+	feed := atomFeed{
+		ID:      "urn:multistream:feed:live:" + token.ID,
+		Title:   "MultiStream live (" + token.Name + ")",
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: selfURL},
+			{Rel: "alternate", Type: "text/html", Href: s.FrontendURL},
+		},
+	}
+
+	for _, e := range entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Published: e.Published.Format(time.RFC3339),
+			Updated:   e.Updated.Format(time.RFC3339),
+			Author:    atomAuthor{Name: e.Streamer.DisplayName},
+			Category:  atomCategory{Term: e.Streamer.Platform},
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: e.WatchURL}},
+			Summary:   e.Summary,
+		}
+		if e.Streamer.Thumbnail != "" {
+			entry.Links = append(entry.Links, atomLink{
+				Rel:  "enclosure",
+				Type: imageType(e.Streamer.Thumbnail),
+				Href: e.Streamer.Thumbnail,
+			})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalFeed(feed)
+}
+
+// LiveRSS renders the live follows visible to a feed token as an RSS 2.0 feed
+func (s *FeedService) LiveRSS(token models.FeedToken) ([]byte, error) {
+	entries, updated := s.liveEntries(token)
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         "MultiStream live (" + token.Name + ")",
+			Link:          s.FrontendURL,
+			Description:   "Followed channels that are live right now",
+			LastBuildDate: updated.Format(time.RFC1123Z),
+			TTL:           5,
+		},
+	}
+
+	for _, e := range entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.WatchURL,
+			Description: e.Summary,
+			Category:    e.Streamer.Platform,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     e.Updated.Format(time.RFC1123Z),
+		}
+		if e.Streamer.Thumbnail != "" {
+			item.Enclosure = &rssEnclosure{
+				URL:  e.Streamer.Thumbnail,
+				Type: imageType(e.Streamer.Thumbnail),
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return marshalFeed(feed)
+}
+
+// liveEntries returns the live follows visible to a token, newest first, and
+// when any of the token's follows last changed status
+func (s *FeedService) liveEntries(token models.FeedToken) ([]liveEntry, time.Time) {
+	var updated time.Time
+	for _, f := range s.Follows.List() {
+		if f.InList(token.Lists) && f.StatusChangedAt != nil && f.StatusChangedAt.After(updated) {
+			updated = *f.StatusChangedAt
+		}
+	}
+
+	entries := make([]liveEntry, 0)
+	for _, f := range s.Follows.Live() {
+		if !f.InList(token.Lists) {
+			continue
+		}
+
+		published := f.AddedAt
+		if f.LiveSince != nil {
+			published = *f.LiveSince
+		}
+		changed := published
+		if f.StatusChangedAt != nil && f.StatusChangedAt.After(changed) {
+			changed = *f.StatusChangedAt
+		}
+		if changed.After(updated) {
+			updated = changed
+		}
+
+		stream := *f.Status
+		entries = append(entries, liveEntry{
+			// A new entry for every time the channel goes live
+			ID:        fmt.Sprintf("urn:multistream:live:%s:%d", f.ID, published.Unix()),
+			Title:     fmt.Sprintf("%s is live: %s", f.DisplayName, stream.Title),
+			Summary:   fmt.Sprintf("%s is live on %s: %s", f.DisplayName, f.Platform, stream.Title),
+			Streamer:  stream,
+			WatchURL:  MultiviewURL(s.FrontendURL, []models.Streamer{stream}),
+			Published: published,
+			Updated:   changed,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Published.After(entries[j].Published)
+	})
+
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+	return entries, updated.UTC()
+}
+
+// imageType guesses the media type of a thumbnail from its URL
+func imageType(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if t := mime.TypeByExtension(path.Ext(u.Path)); t != "" {
+			return t
+		}
+	}
+	return "image/jpeg"
+}
+
+func marshalFeed(feed interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
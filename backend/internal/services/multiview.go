@@ -0,0 +1,43 @@
+package services
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"multistream/backend/internal/models"
+)
+
+// maxMultiviewStreams caps how many streams a generated multiview link opens
+const maxMultiviewStreams = 6
+
+// MultiviewURL builds a frontend link that opens the given streams in a
+// multiview session, using the same ?data= format as the frontend's Start button
+func MultiviewURL(frontendURL string, streams []models.Streamer) string {
+	if len(streams) > maxMultiviewStreams {
+		streams = streams[:maxMultiviewStreams]
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"streams": streams,
+		"settings": map[string]bool{
+			"autoplay": true,
+			"muted":    true,
+			"showChat": false,
+		},
+	})
+
+	return strings.TrimRight(frontendURL, "/") + "/watch?data=" + url.QueryEscape(string(data))
+}
+
+// multiviewStreams puts primary first and fills the rest with other streams
+func multiviewStreams(primary models.Streamer, others []models.Streamer) []models.Streamer {
+	streams := []models.Streamer{primary}
+	for _, s := range others {
+		if s.Platform == primary.Platform && s.ID == primary.ID {
+			continue
+		}
+		streams = append(streams, s)
+	}
+	return streams
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// publicResolveTimeout bounds the DNS lookup that vets a user-supplied host
+const publicResolveTimeout = 5 * time.Second
+
+// errPrivateAddress is returned when a user-supplied URL points at a
+// loopback, link-local, private, carrier-grade NAT or otherwise reserved
+// address
+var errPrivateAddress = errors.New("address is not publicly routable")
+
+// blockedRanges are the non-public ranges the net.IP predicates don't cover
+var blockedRanges = parseCIDRs(
+	"0.0.0.0/8",       // "this network"
+	"100.64.0.0/10",   // carrier-grade NAT, internal on many cloud networks
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved, including broadcast
+	"64:ff9b:1::/48",  // local-use NAT64
+	"2001:db8::/32",   // documentation
+)
+
+// blockedIP reports whether ip is on the backend's own host or network or
+// otherwise not publicly routable
+func blockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified() {
+		return true
+	}
+	for _, block := range blockedRanges {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	blocks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// checkPublicHost resolves a host and rejects it unless every address it
+// has is publicly routable
+func checkPublicHost(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publicResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve host: %w", err)
+	}
+	for _, addr := range addrs {
+		if blockedIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", errPrivateAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// checkPublicDial is a dialer Control hook that refuses to connect to
+// addresses that aren't publicly routable
+func checkPublicDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+// newPublicClient creates a client for user-supplied URLs whose dialer
+// consults control. Addresses are checked when connecting, so a host that
+// resolves somewhere else after it was vetted (DNS rebinding), or a
+// redirect, can't reach the internal network either.
+func newPublicClient(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"multistream/backend/internal/metrics"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/tracing"
+)
+
+const (
+	// maxProviderBackoff caps the delay before a provider call is retried
+	maxProviderBackoff = 5 * time.Second
+
+	// Circuit breaker states
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// ErrProviderUnavailable is returned without calling a provider while its
+// circuit breaker is open
+var ErrProviderUnavailable = errors.New("provider_unavailable")
+
+// Resilience guards the HTTP clients of providers with bounded retries and
+// a circuit breaker per provider
+type Resilience struct {
+	// MaxRetries is how many times an idempotent request is retried after
+	// a network error or retryable status
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles on each
+	// retry and is jittered
+	RetryBackoff time.Duration
+	// FailureThreshold is how many failures in a row open a breaker
+	FailureThreshold int
+	// OpenTimeout is how long an open breaker waits before letting a probe through
+	OpenTimeout time.Duration
+
+	mu       sync.Mutex
+	breakers []*circuitBreaker
+}
+
+// NewResilience creates a resilience layer for provider clients
+func NewResilience(maxRetries int, retryBackoff time.Duration, failureThreshold int, openTimeout time.Duration) *Resilience {
+	return &Resilience{
+		MaxRetries:       maxRetries,
+		RetryBackoff:     retryBackoff,
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      openTimeout,
+	}
+}
+
+// Wrap routes a provider's client through a retrying, breaker-guarded
+// transport. The client's timeout still bounds the whole call, retries
+// included.
+func (r *Resilience) Wrap(provider string, client *http.Client) {
+	breaker := &circuitBreaker{
+		provider:    provider,
+		threshold:   r.FailureThreshold,
+		openTimeout: r.OpenTimeout,
+		state:       breakerClosed,
+	}
+
+	r.mu.Lock()
+	r.breakers = append(r.breakers, breaker)
+	r.mu.Unlock()
+
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &resilientTransport{
+		next:    next,
+		breaker: breaker,
+		retries: r.MaxRetries,
+		backoff: r.RetryBackoff,
+	}
+}
+
+// Breakers returns the state of every provider's circuit breaker
+func (r *Resilience) Breakers() []models.BreakerState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	states := make([]models.BreakerState, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		states = append(states, b.snapshot())
+	}
+	return states
+}
+
+// Breaker returns the state of a provider's circuit breaker, if it has one
+func (r *Resilience) Breaker(provider string) (models.BreakerState, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range r.breakers {
+		if b.provider == provider {
+			return b.snapshot(), true
+		}
+	}
+	return models.BreakerState{}, false
+}
+
+// resilientTransport retries idempotent requests and consults a circuit
+// breaker before each attempt
+type resilientTransport struct {
+	next    http.RoundTripper
+	breaker *circuitBreaker
+	retries int
+	backoff time.Duration
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retries := t.retries
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		retries = 0
+	}
+
+	endpoint := metrics.Endpoint(req.URL)
+	for attempt := 0; ; attempt++ {
+		probe, err := t.breaker.allow()
+		if err != nil {
+			metrics.UpstreamErrors.WithLabelValues(t.breaker.provider, endpoint, "provider_unavailable").Inc()
+			return nil, err
+		}
+
+		// Each attempt gets a span; trace context is not sent to providers
+		ctx, span := tracing.Start(req.Context(), "HTTP "+req.Method+" "+endpoint,
+			attribute.String("provider", t.breaker.provider),
+			attribute.String("http.request.method", req.Method),
+			attribute.Int("http.request.resend_count", attempt),
+		)
+		started := time.Now()
+		resp, err := t.next.RoundTrip(req.WithContext(ctx))
+		failed := err != nil || retryableStatus(resp.StatusCode)
+		observeUpstream(t.breaker.provider, endpoint, resp, err, time.Since(started))
+		if err == nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if failed {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+		}
+		tracing.End(span, &err)
+		if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+			// The caller gave up; that says nothing about the provider
+			t.breaker.release(probe)
+			return nil, err
+		}
+		t.breaker.record(probe, failed, err, resp)
+
+		// A half-open probe is never retried; its outcome decides the breaker
+		if !failed || probe || attempt >= retries {
+			return resp, err
+		}
+
+		delay := retryDelay(t.backoff, attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// observeUpstream records one attempt at a provider call
+func observeUpstream(provider, endpoint string, resp *http.Response, err error, elapsed time.Duration) {
+	metrics.UpstreamDuration.WithLabelValues(provider, endpoint).Observe(elapsed.Seconds())
+	if err != nil {
+		metrics.UpstreamRequests.WithLabelValues(provider, endpoint, "error").Inc()
+		metrics.UpstreamErrors.WithLabelValues(provider, endpoint, "network").Inc()
+		return
+	}
+
+	metrics.UpstreamRequests.WithLabelValues(provider, endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+	if retryableStatus(resp.StatusCode) {
+		metrics.UpstreamErrors.WithLabelValues(provider, endpoint, "status").Inc()
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		metrics.UpstreamRateLimited.WithLabelValues(provider).Inc()
+	}
+}
+
+// retryableStatus reports whether a status means the provider is
+// overloaded or failing rather than rejecting the request
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay is the jittered exponential backoff before retry attempt+1,
+// stretched to a Retry-After the provider asked for
+func retryDelay(backoff time.Duration, attempt int, resp *http.Response) time.Duration {
+	delay := min(backoff<<min(attempt, 10), maxProviderBackoff)
+	// Equal jitter: half fixed, half random, so retries of concurrent
+	// requests spread out without collapsing to zero
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	if resp != nil {
+		if after, err := time.ParseDuration(resp.Header.Get("Retry-After") + "s"); err == nil && after > delay {
+			delay = min(after, maxProviderBackoff)
+		}
+	}
+	return delay
+}
+
+// circuitBreaker stops calls to a provider after threshold failures in a
+// row. Once openTimeout has passed a single probe is let through: success
+// closes the breaker, failure opens it again.
+type circuitBreaker struct {
+	provider    string
+	threshold   int
+	openTimeout time.Duration
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	opens               int
+	openedAt            time.Time
+	probing             bool
+	lastFailure         *time.Time
+	lastError           string
+}
+
+// allow reports whether a call may go ahead, and whether it is the
+// half-open probe
+func (b *circuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen && time.Since(b.openedAt) >= b.openTimeout {
+		b.state = breakerHalfOpen
+	}
+
+	switch {
+	case b.state == breakerClosed:
+		return false, nil
+	case b.state == breakerHalfOpen && !b.probing:
+		b.probing = true
+		return true, nil
+	}
+	return false, fmt.Errorf("%s: %w", b.provider, ErrProviderUnavailable)
+}
+
+// record notes the outcome of a call
+func (b *circuitBreaker) record(probe, failed bool, err error, resp *http.Response) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	if !failed {
+		b.state = breakerClosed
+		b.consecutiveFailures = 0
+		return
+	}
+
+	now := time.Now().UTC()
+	b.consecutiveFailures++
+	b.lastFailure = &now
+	if err != nil {
+		b.lastError = err.Error()
+	} else {
+		b.lastError = fmt.Sprintf("status %d", resp.StatusCode)
+	}
+
+	if probe || (b.state == breakerClosed && b.threshold > 0 && b.consecutiveFailures >= b.threshold) {
+		b.state = breakerOpen
+		b.openedAt = now
+		b.opens++
+	}
+}
+
+// release gives up a probe whose outcome is unknown so the next call probes
+func (b *circuitBreaker) release(probe bool) {
+	if probe {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+	}
+}
+
+func (b *circuitBreaker) snapshot() models.BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := models.BreakerState{
+		Provider:            b.provider,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		Opens:               b.opens,
+		LastFailure:         b.lastFailure,
+		LastError:           b.lastError,
+	}
+	if b.state == breakerOpen {
+		retryAt := b.openedAt.Add(b.openTimeout)
+		state.RetryAt = &retryAt
+	}
+	return state
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var scheduleLog = logging.Component("schedule")
+
+// scheduleGrace keeps a broadcast listed for a while after its scheduled
+// start, since streams often go live a few minutes late
+const scheduleGrace = time.Hour
+
+// ScheduleService tracks upcoming broadcasts of followed channels
+type ScheduleService struct {
+	YouTube  *YouTubeService
+	Twitch   *TwitchService
+	Follows  *FollowService
+	Interval time.Duration
+
+	mu       sync.RWMutex
+	upcoming map[string][]models.Streamer
+	fetched  map[string]time.Time
+	file     *store.JSONFile
+}
+
+// storedSchedule is the on-disk form of the schedule
+type storedSchedule struct {
+	Upcoming map[string][]models.Streamer `json:"upcoming"`
+	Fetched  map[string]time.Time         `json:"fetched"`
+}
+
+// NewScheduleService creates a new schedule service backed by a file in dataDir
+func NewScheduleService(youtube *YouTubeService, twitch *TwitchService, follows *FollowService, dataDir string, interval time.Duration) *ScheduleService {
+	s := &ScheduleService{
+		YouTube:  youtube,
+		Twitch:   twitch,
+		Follows:  follows,
+		Interval: interval,
+		upcoming: make(map[string][]models.Streamer),
+		fetched:  make(map[string]time.Time),
+		file:     store.NewJSONFile(dataDir, "schedule.json"),
+	}
+
+	var saved storedSchedule
+	if err := s.file.Load(&saved); err != nil {
+		scheduleLog.Error("Failed to load schedule", "error", err)
+	}
+	for id, streams := range saved.Upcoming {
+		s.upcoming[id] = streams
+	}
+	for id, fetched := range saved.Fetched {
+		s.fetched[id] = fetched
+	}
+
+	return s
+}
+
+// Upcoming returns the scheduled broadcasts of follows in any of lists (all
+// follows when lists is empty), soonest first
+func (s *ScheduleService) Upcoming(lists []string) []models.Streamer {
+	follows := s.Follows.List()
+	cutoff := time.Now().Add(-scheduleGrace)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	streams := make([]models.Streamer, 0)
+	for _, f := range follows {
+		if !f.InList(lists) {
+			continue
+		}
+		for _, stream := range s.upcoming[f.ID] {
+			if stream.ScheduledStartTime != nil && stream.ScheduledStartTime.After(cutoff) {
+				streams = append(streams, stream)
+			}
+		}
+	}
+
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].ScheduledStartTime.Before(*streams[j].ScheduledStartTime)
+	})
+	return streams
+}
+
+// Observe records a YouTube video we were told about, adding it to its
+// channel's schedule when it is upcoming and dropping it otherwise
+func (s *ScheduleService) Observe(video *models.Streamer) {
+	id := models.FollowID(video.Platform, video.Username)
+	if _, ok := s.Follows.Get(video.Platform, video.Username); !ok {
+		return
+	}
+
+	s.mu.Lock()
+	streams := make([]models.Streamer, 0, len(s.upcoming[id])+1)
+	for _, stream := range s.upcoming[id] {
+		if stream.ID != video.ID {
+			streams = append(streams, stream)
+		}
+	}
+	if video.State == models.StateScheduled && video.ScheduledStartTime != nil {
+		streams = append(streams, *video)
+	}
+	s.upcoming[id] = streams
+	s.mu.Unlock()
+
+	s.save()
+}
+
+// HandleEvent drops a broadcast from the schedule once it goes live
+func (s *ScheduleService) HandleEvent(event models.StreamEvent) {
+	if event.Type != models.EventWentLive {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streams := s.upcoming[event.FollowID]
+	for i, stream := range streams {
+		if event.Platform == "twitch" || stream.ID == event.Streamer.ID {
+			// Twitch segments share the channel ID, so the earliest one started
+			s.upcoming[event.FollowID] = append(streams[:i:i], streams[i+1:]...)
+			return
+		}
+	}
+}
+
+// Run refreshes the schedule of all follows every Interval until ctx is done
+func (s *ScheduleService) Run(ctx context.Context) {
+	if s.Interval <= 0 {
+		scheduleLog.Info("Background refresh disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	s.RefreshAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RefreshAll(ctx)
+		}
+	}
+}
+
+// RefreshAll fetches the upcoming broadcasts of every follow whose platform
+// publishes a schedule
+func (s *ScheduleService) RefreshAll(ctx context.Context) {
+	follows := s.Follows.List()
+
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+
+	for _, f := range follows {
+		if !s.due(f) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f models.Follow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.refresh(ctx, f)
+		}(f)
+	}
+
+	wg.Wait()
+	s.prune(follows)
+	s.save()
+}
+
+// due reports whether a follow's schedule should be fetched this round
+func (s *ScheduleService) due(f models.Follow) bool {
+	switch f.Platform {
+	case "youtube":
+		if s.YouTube.APIKey == "" {
+			return false
+		}
+		// Searches are expensive; once we have a baseline, WebSub tells us
+		// about newly scheduled broadcasts on push-managed channels
+		s.mu.RLock()
+		_, fetched := s.fetched[f.ID]
+		s.mu.RUnlock()
+		return !fetched || !s.Follows.PushManaged(f)
+	case "twitch":
+		return s.Twitch.Configured()
+	default:
+		// Kick has no schedules
+		return false
+	}
+}
+
+// refresh fetches the upcoming broadcasts of a single follow
+func (s *ScheduleService) refresh(ctx context.Context, f models.Follow) {
+	var streams []models.Streamer
+	var err error
+
+	switch f.Platform {
+	case "youtube":
+		streams, err = s.YouTube.GetUpcomingStreams(ctx, f.ChannelID)
+	case "twitch":
+		streams, err = s.Twitch.GetSchedule(ctx, f.ChannelID)
+	}
+
+	if err != nil {
+		scheduleLog.WarnContext(ctx, "Failed to fetch schedule", "follow", f.ID, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.upcoming[f.ID] = streams
+	s.fetched[f.ID] = time.Now().UTC()
+	s.mu.Unlock()
+}
+
+// prune forgets unfollowed channels and broadcasts that never started
+func (s *ScheduleService) prune(follows []models.Follow) {
+	followed := make(map[string]bool, len(follows))
+	for _, f := range follows {
+		followed[f.ID] = true
+	}
+	cutoff := time.Now().Add(-scheduleGrace)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, streams := range s.upcoming {
+		if !followed[id] {
+			delete(s.upcoming, id)
+			delete(s.fetched, id)
+			continue
+		}
+
+		kept := streams[:0]
+		for _, stream := range streams {
+			if stream.ScheduledStartTime != nil && stream.ScheduledStartTime.After(cutoff) {
+				kept = append(kept, stream)
+			}
+		}
+		s.upcoming[id] = kept
+	}
+}
+
+func (s *ScheduleService) save() {
+	s.mu.RLock()
+	saved := storedSchedule{Upcoming: s.upcoming, Fetched: s.fetched}
+	err := s.file.Save(saved)
+	s.mu.RUnlock()
+
+	if err != nil {
+		scheduleLog.Error("Failed to save schedule", "error", err)
+	}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+	"multistream/backend/internal/tracing"
+)
+
+var searchLog = logging.Component("search")
+
+// SearchRequest is a stream search as the API receives it
+type SearchRequest struct {
+	Platform string
+	Query    string
+	Limit    int
+	Filter   SearchFilter
+	// Cursor continues a previous search; empty starts a new one
+	Cursor string
+}
+
+// SearchResult is one page of search results
+type SearchResult struct {
+	Streamers []models.Streamer
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string
+	// Offline lists the providers that failed and were answered from the
+	// local channel index instead
+	Offline []string
+}
+
+// searchCursor is the continuation state sealed into a search cursor
+type searchCursor struct {
+	// Search fingerprints the request, so a cursor only continues its own search
+	Search  string `json:"s"`
+	YouTube string `json:"yt,omitempty"`
+	// Kick is an offset into Kick's result list
+	Kick   int    `json:"ko,omitempty"`
+	Twitch string `json:"tw,omitempty"`
+	// Done lists the providers that have no more pages
+	Done []string `json:"done,omitempty"`
+}
+
+// searchState is the persisted state of the search service
+type searchState struct {
+	CursorSecret string `json:"cursorSecret"`
+}
+
+// SearchService searches streams across providers and pages through the
+// results with signed cursors
+type SearchService struct {
+	YouTube *YouTubeService
+	Kick    *KickService
+	Twitch  *TwitchService
+	// Channels, when set, answers for providers that fail
+	Channels *ChannelIndex
+
+	cursors *CursorSigner
+}
+
+// NewSearchService creates a new search service. When cursorSecret is empty
+// a random one is generated and stored in dataDir, so cursors survive restarts.
+func NewSearchService(youtube *YouTubeService, kick *KickService, twitch *TwitchService, cursorSecret, dataDir string) *SearchService {
+	if cursorSecret == "" {
+		file := store.NewJSONFile(dataDir, "search.json")
+
+		var state searchState
+		if err := file.Load(&state); err != nil {
+			searchLog.Error("Failed to load cursor secret", "error", err)
+		}
+		if state.CursorSecret == "" {
+			state.CursorSecret = newID()
+			if err := file.Save(state); err != nil {
+				searchLog.Error("Failed to save cursor secret", "error", err)
+			}
+		}
+		cursorSecret = state.CursorSecret
+	}
+
+	return &SearchService{
+		YouTube: youtube,
+		Kick:    kick,
+		Twitch:  twitch,
+		cursors: NewCursorSigner(cursorSecret),
+	}
+}
+
+// Search returns one page of results and the cursor of the next page, which
+// is empty once every provider is exhausted. The limit is shared between the
+// providers with pages left; what one leaves unused, after filtering and
+// deduplication, is offered once to the others. Results are then ranked
+// together by relevance. On the first page, a provider that fails is
+// answered from the local channel index when it knows matching channels.
+func (s *SearchService) Search(ctx context.Context, req SearchRequest) (*SearchResult, error) {
+	providers, ok := s.providers(req.Platform)
+	if !ok {
+		return nil, fmt.Errorf("invalid platform: must be youtube, kick, twitch, or all")
+	}
+
+	fingerprint := searchFingerprint(req)
+	state := searchCursor{Search: fingerprint}
+	if req.Cursor != "" {
+		if err := s.cursors.Decode(req.Cursor, &state); err != nil {
+			return nil, err
+		}
+		if state.Search != fingerprint {
+			return nil, fmt.Errorf("%w: it belongs to a different search", ErrInvalidCursor)
+		}
+	}
+
+	// A provider that fails keeps its position, so the next page retries it
+	next := state
+	next.Done = slices.Clone(state.Done)
+
+	pending := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if !slices.Contains(state.Done, provider) {
+			pending = append(pending, provider)
+		}
+	}
+
+	merge := newSearchMerge(req.Query, req.Filter)
+	quotas := splitQuota(req.Limit, pending)
+	result := &SearchResult{}
+	var firstErr error
+	attempted, failed, unused := 0, 0, 0
+	var donors []string
+
+	for _, provider := range pending {
+		if quotas[provider] == 0 {
+			continue
+		}
+		attempted++
+		found, done, err := s.fetch(ctx, provider, req, quotas[provider], &next)
+		if err != nil {
+			searchLog.WarnContext(ctx, "Provider search failed", "provider", provider, "error", err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			unused += quotas[provider] - s.searchOffline(ctx, provider, req, quotas[provider], merge, result)
+			continue
+		}
+
+		added := merge.add(provider, found)
+		unused += quotas[provider] - added
+		if done {
+			next.Done = append(next.Done, provider)
+		} else if added >= quotas[provider] {
+			donors = append(donors, provider)
+		}
+	}
+
+	// Fail only when every provider did and nothing was known about them
+	if attempted > 0 && failed == attempted && len(result.Offline) == 0 {
+		return nil, firstErr
+	}
+
+	// Fill what went unused with more from the providers that had plenty
+	refills := splitQuota(unused, donors)
+	for _, provider := range donors {
+		if refills[provider] == 0 {
+			continue
+		}
+		found, done, err := s.fetch(ctx, provider, req, refills[provider], &next)
+		if err != nil {
+			searchLog.WarnContext(ctx, "Provider search failed", "provider", provider, "error", err)
+			continue
+		}
+		merge.add(provider, found)
+		if done {
+			next.Done = append(next.Done, provider)
+		}
+	}
+
+	if len(next.Done) < len(providers) {
+		var err error
+		if result.NextCursor, err = s.cursors.Encode(next); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Streamers = merge.result()
+	return result, nil
+}
+
+// providers lists the providers searched for a platform parameter. Searches
+// of every platform include Twitch when its credentials are set.
+func (s *SearchService) providers(platform string) ([]string, bool) {
+	switch platform {
+	case "youtube", "kick", "twitch":
+		return []string{platform}, true
+	case "", "all":
+		if s.Twitch.Configured() {
+			return []string{"youtube", "kick", "twitch"}, true
+		}
+		return []string{"youtube", "kick"}, true
+	}
+	return nil, false
+}
+
+// searchOffline answers for a failed provider from the local channel index
+// and returns how many results it added. Only first pages are answered, as
+// the index can't continue where the provider left off.
+func (s *SearchService) searchOffline(ctx context.Context, provider string, req SearchRequest, limit int, merge *searchMerge, result *SearchResult) int {
+	if s.Channels == nil || req.Cursor != "" {
+		return 0
+	}
+
+	_, span := tracing.Start(ctx, "search.offline", attribute.String("search.provider", provider))
+	defer span.End()
+
+	found := s.Channels.Search(req.Query, []string{provider}, limit)
+	span.SetAttributes(attribute.Int("search.results", len(found)))
+	if len(found) == 0 {
+		return 0
+	}
+	result.Offline = append(result.Offline, provider)
+	return merge.add(provider, found)
+}
+
+// fetch gets up to limit results from a provider, continuing from and
+// advancing its position in next. It reports whether the provider is done.
+func (s *SearchService) fetch(ctx context.Context, provider string, req SearchRequest, limit int, next *searchCursor) ([]models.Streamer, bool, error) {
+	switch provider {
+	case "youtube":
+		found, token, err := s.searchYouTube(ctx, req.Query, req.Filter, limit, next.YouTube)
+		if err != nil {
+			return nil, false, err
+		}
+		next.YouTube = token
+		return found, token == "", nil
+	case "kick":
+		found, more, err := s.searchKick(ctx, req.Query, req.Filter, limit, next.Kick)
+		if err != nil {
+			return nil, false, err
+		}
+		next.Kick += limit
+		return found, !more, nil
+	case "twitch":
+		found, after, err := s.searchTwitch(ctx, req.Query, req.Filter, limit, next.Twitch)
+		if err != nil {
+			return nil, false, err
+		}
+		next.Twitch = after
+		return found, after == "", nil
+	}
+	return nil, true, nil
+}
+
+// searchFingerprint identifies everything about a search but its position
+func searchFingerprint(req SearchRequest) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%+v", req.Platform, req.Query, req.Limit, req.Filter)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// searchYouTube searches YouTube, pushing down the filters its API supports.
+// It returns the token of the next page, empty on the last one.
+func (s *SearchService) searchYouTube(ctx context.Context, query string, filter SearchFilter, limit int, pageToken string) ([]models.Streamer, string, error) {
+	opts := YouTubeSearchOptions{
+		Query:      query,
+		Language:   filter.Language,
+		MaxResults: limit,
+		PageToken:  pageToken,
+	}
+
+	switch filter.State {
+	case "live":
+		opts.EventType = "live"
+	case "upcoming":
+		opts.EventType = "upcoming"
+	}
+
+	switch filter.Sort {
+	case SortViewers:
+		opts.Order = "viewCount"
+	case SortRecent:
+		opts.Order = "date"
+	}
+
+	// Search results don't name games, so look for the category instead
+	if filter.Category != "" {
+		opts.Query += " " + filter.Category
+	}
+	if filter.ExcludeMature {
+		opts.SafeSearch = "strict"
+	}
+
+	streamers, next, err := s.YouTube.SearchPage(ctx, opts)
+	if err != nil || filter.Category == "" {
+		return streamers, next, err
+	}
+
+	// The category was searched for, so it is what these results are about
+	for i := range streamers {
+		if streamers[i].Category == "" {
+			streamers[i].Category = filter.Category
+		}
+	}
+	return streamers, next, nil
+}
+
+// searchKick searches Kick, which has no search filters of its own,
+// starting at offset. It reports whether more results follow.
+func (s *SearchService) searchKick(ctx context.Context, query string, filter SearchFilter, limit, offset int) ([]models.Streamer, bool, error) {
+	if filter.State == "upcoming" {
+		// Kick has no scheduled streams
+		return []models.Streamer{}, false, nil
+	}
+
+	return s.Kick.SearchChannelsFrom(ctx, query, offset, limit)
+}
+
+// searchTwitch searches Twitch channels, pushing down the live filter. It
+// returns the cursor of the next page, empty on the last one.
+func (s *SearchService) searchTwitch(ctx context.Context, query string, filter SearchFilter, limit int, after string) ([]models.Streamer, string, error) {
+	if filter.State == "upcoming" {
+		// Channel search doesn't cover schedules
+		return []models.Streamer{}, "", nil
+	}
+
+	return s.Twitch.SearchChannelsPage(ctx, query, filter.State == "live", limit, after)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"multistream/backend/internal/models"
+)
+
+// Search sort orders
+const (
+	SortRelevance = "relevance"
+	SortViewers   = "viewers"
+	SortRecent    = "recent"
+	SortUptime    = "uptime"
+)
+
+// SearchFilter narrows and orders search results. Providers push down what
+// their APIs support; Apply enforces all of it on whatever comes back, so
+// results are consistent across providers. Streams missing a filtered field
+// (such as Kick search results, which carry no language) are excluded.
+// Viewer bounds and the viewers sort count current viewers, so streams that
+// aren't live count as having none.
+type SearchFilter struct {
+	// State is live or upcoming
+	State         string
+	MinViewers    int
+	MaxViewers    int
+	Language      string
+	Category      string
+	VerifiedOnly  bool
+	ExcludeMature bool
+	Sort          string
+}
+
+// Active reports whether the filter removes any results
+func (f SearchFilter) Active() bool {
+	return f.State != "" || f.MinViewers > 0 || f.MaxViewers > 0 || f.Language != "" ||
+		f.Category != "" || f.VerifiedOnly || f.ExcludeMature
+}
+
+// Validate checks the filter for contradictions and unknown values
+func (f SearchFilter) Validate() error {
+	switch f.State {
+	case "", "live", "upcoming":
+	default:
+		return fmt.Errorf("invalid state: must be live or upcoming")
+	}
+
+	switch f.Sort {
+	case "", SortRelevance, SortViewers, SortRecent, SortUptime:
+	default:
+		return fmt.Errorf("invalid sort: must be relevance, viewers, recent, or uptime")
+	}
+
+	if f.MinViewers < 0 || f.MaxViewers < 0 {
+		return fmt.Errorf("viewer bounds must not be negative")
+	}
+	if f.MaxViewers > 0 && f.MinViewers > f.MaxViewers {
+		return fmt.Errorf("min_viewers must not exceed max_viewers")
+	}
+	return nil
+}
+
+// Match reports whether a stream passes the filter
+func (f SearchFilter) Match(st models.Streamer) bool {
+	switch {
+	case f.State == "live" && !st.IsLive:
+		return false
+	case f.State == "upcoming" && st.State != models.StateScheduled:
+		return false
+	case f.MinViewers > 0 && liveViewers(st) < f.MinViewers:
+		return false
+	case f.MaxViewers > 0 && liveViewers(st) > f.MaxViewers:
+		return false
+	case f.Language != "" && !MatchLanguage(st.Language, f.Language):
+		return false
+	case f.Category != "" && categoryKey(st.Category) != categoryKey(f.Category):
+		return false
+	case f.VerifiedOnly && !st.Verified:
+		return false
+	case f.ExcludeMature && st.Mature:
+		return false
+	}
+	return true
+}
+
+// Apply filters streams and sorts them. Relevance keeps the providers' order.
+func (f SearchFilter) Apply(streams []models.Streamer) []models.Streamer {
+	filtered := make([]models.Streamer, 0, len(streams))
+	for _, st := range streams {
+		if f.Match(st) {
+			filtered = append(filtered, st)
+		}
+	}
+
+	switch f.Sort {
+	case SortViewers:
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return liveViewers(filtered[i]) > liveViewers(filtered[j])
+		})
+	case SortRecent:
+		// Most recently started first, then soonest scheduled
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return startedAfter(filtered[i], filtered[j])
+		})
+	case SortUptime:
+		// Longest running first; streams without a start time last
+		sort.SliceStable(filtered, func(i, j int) bool {
+			a, b := filtered[i].ActualStartTime, filtered[j].ActualStartTime
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return a.Before(*b)
+		})
+	}
+
+	return filtered
+}
+
+// liveViewers is how many watch a stream now. YouTube reports a video's
+// lifetime views as its viewer count when it isn't live, which would
+// otherwise outrank concurrent viewers.
+func liveViewers(st models.Streamer) int {
+	if !st.IsLive {
+		return 0
+	}
+	return st.ViewerCount
+}
+
+// startedAfter orders streams by start time (actual, else scheduled), newest
+// first, with streams lacking both last
+func startedAfter(a, b models.Streamer) bool {
+	at, bt := a.ActualStartTime, b.ActualStartTime
+	if at == nil {
+		at = a.ScheduledStartTime
+	}
+	if bt == nil {
+		bt = b.ScheduledStartTime
+	}
+	if at == nil || bt == nil {
+		return at != nil
+	}
+	return at.After(*bt)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"multistream/backend/internal/models"
+)
+
+// Weights of the parts of a search result's relevance score. Each part is
+// normalized to 0-1, so the score is too and providers compare fairly.
+const (
+	matchWeight    = 0.4
+	rankWeight     = 0.2
+	liveWeight     = 0.2
+	viewerWeight   = 0.2
+	viewerCeiling  = 6 // log10 of the viewer count that scores full marks
+	rankHalfLife   = 10
+	titleHitFactor = 0.5
+)
+
+// scoredStream is a search result with its relevance score
+type scoredStream struct {
+	stream models.Streamer
+	score  float64
+}
+
+// searchMerge collects the results of several providers into one page. It
+// drops results that fail the filter and keeps only the best video of each
+// YouTube channel.
+type searchMerge struct {
+	query    string
+	filter   SearchFilter
+	streams  []scoredStream
+	channels map[string]int
+	seen     map[string]int
+}
+
+func newSearchMerge(query string, filter SearchFilter) *searchMerge {
+	return &searchMerge{
+		query:    query,
+		filter:   filter,
+		channels: make(map[string]int),
+		seen:     make(map[string]int),
+	}
+}
+
+// add merges a provider's results, given in the provider's own order, and
+// returns how many of them made it onto the page
+func (m *searchMerge) add(provider string, found []models.Streamer) int {
+	added := 0
+	for _, st := range found {
+		// Position in the provider's own ranking, across refills
+		position := m.seen[provider]
+		m.seen[provider]++
+
+		if !m.filter.Match(st) {
+			continue
+		}
+		scored := scoredStream{stream: st, score: relevanceScore(m.query, st, position)}
+
+		if st.Platform == "youtube" && st.Username != "" {
+			if i, ok := m.channels[st.Username]; ok {
+				if scored.score > m.streams[i].score {
+					m.streams[i] = scored
+				}
+				continue
+			}
+			m.channels[st.Username] = len(m.streams)
+		}
+
+		m.streams = append(m.streams, scored)
+		added++
+	}
+	return added
+}
+
+// result returns the page, most relevant first, then in the filter's order
+func (m *searchMerge) result() []models.Streamer {
+	sort.SliceStable(m.streams, func(i, j int) bool {
+		return m.streams[i].score > m.streams[j].score
+	})
+
+	streams := make([]models.Streamer, 0, len(m.streams))
+	for _, s := range m.streams {
+		streams = append(streams, s.stream)
+	}
+	return m.filter.Apply(streams)
+}
+
+// relevanceScore rates a result for query from how well it matches, where
+// its provider ranked it, whether it is live and how many watch it now
+func relevanceScore(query string, st models.Streamer, position int) float64 {
+	live := 0.0
+	switch st.State {
+	case models.StateLive:
+		live = 1
+	case models.StateScheduled:
+		live = 0.5
+	}
+
+	viewers := math.Min(math.Log10(float64(liveViewers(st))+1)/viewerCeiling, 1)
+	rank := 1 / (1 + float64(position)/rankHalfLife)
+
+	return matchWeight*matchQuality(query, st) + rankWeight*rank + liveWeight*live + viewerWeight*viewers
+}
+
+// matchQuality rates how well a result matches query: an exact channel name
+// scores 1, a name starting with or containing the query less, and otherwise
+// the share of query words found in the title and name counts for half
+func matchQuality(query string, st models.Streamer) float64 {
+	want := searchKey(query)
+	if want == "" {
+		return 0
+	}
+
+	best := 0.0
+	for _, name := range []string{st.DisplayName, st.Username} {
+		have := searchKey(name)
+		switch {
+		case have == "":
+		case have == want:
+			return 1
+		case strings.HasPrefix(have, want):
+			best = math.Max(best, 0.8)
+		case strings.Contains(have, want):
+			best = math.Max(best, 0.6)
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	text := strings.ToLower(st.Title + " " + st.DisplayName)
+	hits := 0
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			hits++
+		}
+	}
+	return math.Max(best, titleHitFactor*float64(hits)/float64(len(words)))
+}
+
+// searchKey reduces text to lowercase letters and digits, so "xQc", "xqc"
+// and "x_q_c" match
+func searchKey(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// splitQuota shares limit between providers, giving any remainder to the
+// first ones so none of the limit is lost
+func splitQuota(limit int, providers []string) map[string]int {
+	quotas := make(map[string]int, len(providers))
+	if len(providers) == 0 {
+		return quotas
+	}
+	for i, p := range providers {
+		quotas[p] = limit / len(providers)
+		if i < limit%len(providers) {
+			quotas[p]++
+		}
+	}
+	return quotas
+}
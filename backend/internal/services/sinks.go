@@ -0,0 +1,153 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"multistream/backend/internal/models"
+)
+
+// Embed colours per platform
+var platformColors = map[string]int{
+	"youtube": 0xFF0000,
+	"kick":    0x53FC18,
+	"twitch":  0x9146FF,
+}
+
+// Human readable names per platform
+var platformNames = map[string]string{
+	"youtube": "YouTube",
+	"kick":    "Kick",
+	"twitch":  "Twitch",
+}
+
+// renderPayload renders an event in the body format a webhook kind expects
+func renderPayload(kind string, event models.StreamEvent, watchURL string) ([]byte, error) {
+	switch kind {
+	case models.WebhookDiscord:
+		return json.Marshal(discordPayload(event, watchURL))
+	case models.WebhookSlack:
+		return json.Marshal(slackPayload(event, watchURL))
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// eventHeadline is the one-line summary of an event used by chat sinks
+func eventHeadline(event models.StreamEvent) string {
+	name := event.Streamer.DisplayName
+	platform := platformName(event.Platform)
+
+	switch event.Type {
+	case models.EventWentLive:
+		return fmt.Sprintf("%s is now live on %s", name, platform)
+	case models.EventWentOffline:
+		return fmt.Sprintf("%s went offline on %s", name, platform)
+	case models.EventTitleChanged:
+		return fmt.Sprintf("%s changed their stream title on %s", name, platform)
+	default:
+		return fmt.Sprintf("%s: %s", name, event.Type)
+	}
+}
+
+func platformName(platform string) string {
+	if name, ok := platformNames[platform]; ok {
+		return name
+	}
+	return platform
+}
+
+// discordPayload renders an event as a Discord webhook message with a rich embed
+func discordPayload(event models.StreamEvent, watchURL string) map[string]interface{} {
+	s := event.Streamer
+
+	fields := []map[string]interface{}{
+		{"name": "Platform", "value": platformName(event.Platform), "inline": true},
+	}
+	if s.IsLive {
+		fields = append(fields, map[string]interface{}{
+			"name": "Viewers", "value": fmt.Sprintf("%d", s.ViewerCount), "inline": true,
+		})
+	}
+	if event.PreviousTitle != "" {
+		fields = append(fields, map[string]interface{}{
+			"name": "Previous title", "value": event.PreviousTitle, "inline": false,
+		})
+	}
+
+	embed := map[string]interface{}{
+		"title":       s.Title,
+		"description": eventHeadline(event),
+		"url":         watchURL,
+		"color":       platformColors[event.Platform],
+		"fields":      fields,
+		"timestamp":   event.OccurredAt.Format("2006-01-02T15:04:05Z07:00"),
+		"author":      map[string]string{"name": s.DisplayName},
+		"footer":      map[string]string{"text": "MultiStream"},
+	}
+	if s.Thumbnail != "" {
+		embed["image"] = map[string]string{"url": s.Thumbnail}
+	}
+
+	return map[string]interface{}{
+		"username": "MultiStream",
+		"embeds":   []interface{}{embed},
+	}
+}
+
+// slackPayload renders an event as a Slack incoming webhook message using Block Kit
+func slackPayload(event models.StreamEvent, watchURL string) map[string]interface{} {
+	s := event.Streamer
+	headline := eventHeadline(event)
+
+	text := fmt.Sprintf("*%s*\n<%s|%s>", headline, watchURL, s.Title)
+	if s.IsLive {
+		text += fmt.Sprintf("\n:busts_in_silhouette: %d viewers", s.ViewerCount)
+	}
+	if event.PreviousTitle != "" {
+		text += fmt.Sprintf("\n_Previously:_ %s", event.PreviousTitle)
+	}
+
+	section := map[string]interface{}{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": text},
+	}
+	if s.Thumbnail != "" {
+		section["accessory"] = map[string]string{
+			"type":      "image",
+			"image_url": s.Thumbnail,
+			"alt_text":  s.DisplayName,
+		}
+	}
+
+	blocks := []interface{}{
+		section,
+		map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]string{
+				{"type": "mrkdwn", "text": platformName(event.Platform)},
+			},
+		},
+		map[string]interface{}{
+			"type": "actions",
+			"elements": []map[string]interface{}{
+				{
+					"type": "button",
+					"text": map[string]string{"type": "plain_text", "text": "Watch in MultiStream"},
+					"url":  watchURL,
+				},
+			},
+		},
+	}
+
+	// Blocks go inside an attachment so the message gets the platform colour bar
+	return map[string]interface{}{
+		"text": headline,
+		"attachments": []map[string]interface{}{
+			{
+				"color":  fmt.Sprintf("#%06X", platformColors[event.Platform]),
+				"blocks": blocks,
+			},
+		},
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+const sinkTestFrontend = "http://multistream.test"
+
+// sinkStandIn stands in for Discord and Slack, handing over each body it
+// receives keyed by request path
+func sinkStandIn(t *testing.T) (*httptest.Server, <-chan map[string][]byte) {
+	t.Helper()
+	received := make(chan map[string][]byte, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- map[string][]byte{r.URL.Path: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// deliverToSink registers a webhook of kind on the stand-in and returns the
+// body it receives for event
+func deliverToSink(t *testing.T, kind string, event models.StreamEvent) map[string]interface{} {
+	t.Helper()
+	server, received := sinkStandIn(t)
+
+	dataDir := t.TempDir()
+	s := NewWebhookService(nil, sinkTestFrontend, dataDir, 1, time.Millisecond)
+	s.AllowPrivateNetworks = true
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s.Run(ctx)
+
+	if _, err := s.Create(models.WebhookRequest{URL: server.URL + "/" + kind, Kind: kind}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	s.HandleEvent(event)
+
+	select {
+	case got := <-received:
+		body, ok := got["/"+kind]
+		if !ok {
+			t.Fatalf("delivery went to %v, want /%s", got, kind)
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("body is not JSON: %v\n%s", err, body)
+		}
+		waitDelivered(t, dataDir)
+		return payload
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery received")
+	}
+	return nil
+}
+
+func sinkTestEvent() models.StreamEvent {
+	return models.StreamEvent{
+		ID:        "event-1",
+		Type:      models.EventWentLive,
+		FollowID:  models.FollowID("twitch", "shroud"),
+		Platform:  "twitch",
+		ChannelID: "shroud",
+		Streamer: models.Streamer{
+			ID:          "shroud",
+			Platform:    "twitch",
+			Username:    "shroud",
+			DisplayName: "shroud",
+			Title:       "ranked grind",
+			Thumbnail:   "https://static-cdn.jtvnw.net/previews-ttv/live_user_shroud.jpg",
+			ViewerCount: 25000,
+			IsLive:      true,
+			State:       models.StateLive,
+		},
+		OccurredAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// checkDeepLink asserts that link opens a multiview of the event's stream
+func checkDeepLink(t *testing.T, link string) {
+	t.Helper()
+	prefix := sinkTestFrontend + "/watch?data="
+	if !strings.HasPrefix(link, prefix) {
+		t.Fatalf("deep link = %q, want prefix %q", link, prefix)
+	}
+	data, err := url.QueryUnescape(strings.TrimPrefix(link, prefix))
+	if err != nil {
+		t.Fatalf("deep link data: %v", err)
+	}
+	var session struct {
+		Streams []models.Streamer `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		t.Fatalf("deep link data is not JSON: %v", err)
+	}
+	if len(session.Streams) != 1 || session.Streams[0].Platform != "twitch" || session.Streams[0].ID != "shroud" {
+		t.Errorf("deep link streams = %+v, want twitch/shroud", session.Streams)
+	}
+}
+
+func TestDiscordSink(t *testing.T) {
+	event := sinkTestEvent()
+	payload := deliverToSink(t, models.WebhookDiscord, event)
+
+	embeds, _ := payload["embeds"].([]interface{})
+	if len(embeds) != 1 {
+		t.Fatalf("embeds = %v, want one embed", payload["embeds"])
+	}
+	embed := embeds[0].(map[string]interface{})
+
+	if got := int(embed["color"].(float64)); got != 0x9146FF {
+		t.Errorf("color = %#x, want Twitch purple %#x", got, 0x9146FF)
+	}
+	if got := embed["title"]; got != event.Streamer.Title {
+		t.Errorf("title = %v, want %q", got, event.Streamer.Title)
+	}
+	if got := embed["description"]; got != "shroud is now live on Twitch" {
+		t.Errorf("description = %v", got)
+	}
+	image, _ := embed["image"].(map[string]interface{})
+	if image["url"] != event.Streamer.Thumbnail {
+		t.Errorf("image = %v, want %q", embed["image"], event.Streamer.Thumbnail)
+	}
+	checkDeepLink(t, embed["url"].(string))
+}
+
+func TestSlackSink(t *testing.T) {
+	event := sinkTestEvent()
+	payload := deliverToSink(t, models.WebhookSlack, event)
+
+	if got := payload["text"]; got != "shroud is now live on Twitch" {
+		t.Errorf("text = %v", got)
+	}
+	attachments, _ := payload["attachments"].([]interface{})
+	if len(attachments) != 1 {
+		t.Fatalf("attachments = %v, want one attachment", payload["attachments"])
+	}
+	attachment := attachments[0].(map[string]interface{})
+	if got := attachment["color"]; got != "#9146FF" {
+		t.Errorf("color = %v, want #9146FF", got)
+	}
+
+	blocks, _ := attachment["blocks"].([]interface{})
+	types := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		types = append(types, b.(map[string]interface{})["type"].(string))
+	}
+	if strings.Join(types, ",") != "section,context,actions" {
+		t.Fatalf("block types = %v, want section, context, actions", types)
+	}
+
+	accessory, _ := blocks[0].(map[string]interface{})["accessory"].(map[string]interface{})
+	if accessory["type"] != "image" || accessory["image_url"] != event.Streamer.Thumbnail {
+		t.Errorf("section accessory = %v, want the thumbnail", accessory)
+	}
+
+	buttons := blocks[2].(map[string]interface{})["elements"].([]interface{})
+	checkDeepLink(t, buttons[0].(map[string]interface{})["url"].(string))
+}
+
+func TestSinkListScoping(t *testing.T) {
+	server, received := sinkStandIn(t)
+
+	dataDir := t.TempDir()
+	s := NewWebhookService(nil, sinkTestFrontend, dataDir, 1, time.Millisecond)
+	s.AllowPrivateNetworks = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Run(ctx)
+
+	if _, err := s.Create(models.WebhookRequest{URL: server.URL + "/games", Kind: models.WebhookDiscord, Lists: []string{"games"}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := s.Create(models.WebhookRequest{URL: server.URL + "/music", Kind: models.WebhookSlack, Lists: []string{"music"}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	event := sinkTestEvent()
+	event.Lists = []string{"games"}
+	s.HandleEvent(event)
+
+	select {
+	case got := <-received:
+		if _, ok := got["/games"]; !ok {
+			t.Errorf("delivery went to %v, want /games", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery received")
+	}
+	select {
+	case got := <-received:
+		t.Errorf("unexpected delivery to %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+	waitDelivered(t, dataDir)
+}
+
+// waitDelivered waits until every delivery saved in dataDir is finished,
+// so workers are done writing there before the test removes it
+func waitDelivered(t *testing.T, dataDir string) {
+	t.Helper()
+	file := store.NewJSONFile(dataDir, "deliveries.json")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var deliveries []models.Delivery
+		file.Load(&deliveries)
+		pending := len(deliveries) == 0
+		for _, d := range deliveries {
+			pending = pending || d.Status == models.DeliveryPending
+		}
+		if !pending {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("deliveries still pending")
+}
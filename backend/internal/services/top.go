@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/metrics"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/tracing"
+)
+
+var topLog = logging.Component("top")
+
+// topFetchLimit is how many streams are fetched from each provider per leaderboard
+const topFetchLimit = 100
+
+// TopService ranks live streams across providers by viewers
+type TopService struct {
+	YouTube *YouTubeService
+	Kick    *KickService
+	Twitch  *TwitchService
+	TTL     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]topEntry
+}
+
+// topEntry is a cached leaderboard
+type topEntry struct {
+	streams   []models.Streamer
+	fetchedAt time.Time
+}
+
+// CacheSize returns how many leaderboards are cached
+func (s *TopService) CacheSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cache)
+}
+
+// cacheLookup records a cache hit or miss in the metrics and the trace
+func cacheLookup(ctx context.Context, cache string, hit bool) {
+	metrics.CacheLookup(cache, hit)
+	tracing.CacheLookup(ctx, cache, hit)
+}
+
+// TopFilter narrows the leaderboard
+type TopFilter struct {
+	Platform string
+	Category string
+	Language string
+}
+
+// NewTopService creates a new leaderboard service caching results for ttl
+func NewTopService(youtube *YouTubeService, kick *KickService, twitch *TwitchService, ttl time.Duration) *TopService {
+	return &TopService{
+		YouTube: youtube,
+		Kick:    kick,
+		Twitch:  twitch,
+		TTL:     ttl,
+		cache:   make(map[string]topEntry),
+	}
+}
+
+// Top returns the live streams matching filter, most watched first, and
+// when they were fetched
+func (s *TopService) Top(ctx context.Context, filter TopFilter) ([]models.Streamer, time.Time, error) {
+	key := strings.ToLower(filter.Platform + "|" + filter.Category + "|" + filter.Language)
+
+	s.mu.Lock()
+	entry, ok := s.cache[key]
+	s.mu.Unlock()
+	hit := ok && time.Since(entry.fetchedAt) < s.TTL
+	cacheLookup(ctx, "top", hit)
+	if hit {
+		return entry.streams, entry.fetchedAt, nil
+	}
+
+	streams, err := s.fetch(ctx, filter)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	entry = topEntry{streams: streams, fetchedAt: time.Now().UTC()}
+	s.mu.Lock()
+	s.cache[key] = entry
+	s.prune()
+	s.mu.Unlock()
+
+	return entry.streams, entry.fetchedAt, nil
+}
+
+// fetch asks every selected provider for its top streams and merges them
+func (s *TopService) fetch(ctx context.Context, filter TopFilter) ([]models.Streamer, error) {
+	providers := map[string]func(context.Context, TopFilter) ([]models.Streamer, error){
+		"youtube": s.topYouTube,
+		"kick":    s.topKick,
+		"twitch":  s.topTwitch,
+	}
+
+	if filter.Platform != "" && filter.Platform != "all" {
+		provider, ok := providers[filter.Platform]
+		if !ok {
+			return nil, fmt.Errorf("invalid platform: must be youtube, kick, twitch, or all")
+		}
+		providers = map[string]func(context.Context, TopFilter) ([]models.Streamer, error){filter.Platform: provider}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	streams := make([]models.Streamer, 0)
+	var errs []string
+
+	for platform, provider := range providers {
+		wg.Add(1)
+		go func(platform string, provider func(context.Context, TopFilter) ([]models.Streamer, error)) {
+			defer wg.Done()
+			found, err := provider(ctx, filter)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				topLog.WarnContext(ctx, "Provider failed", "platform", platform, "error", err)
+				errs = append(errs, fmt.Sprintf("%s: %v", platform, err))
+				return
+			}
+			streams = append(streams, found...)
+		}(platform, provider)
+	}
+	wg.Wait()
+
+	// Only fail when no provider could answer
+	if len(errs) == len(providers) {
+		return nil, fmt.Errorf("all providers failed: %s", strings.Join(errs, "; "))
+	}
+
+	live := streams[:0]
+	for _, st := range streams {
+		if st.IsLive {
+			live = append(live, st)
+		}
+	}
+
+	sort.SliceStable(live, func(i, j int) bool {
+		return live[i].ViewerCount > live[j].ViewerCount
+	})
+	return live, nil
+}
+
+func (s *TopService) topYouTube(ctx context.Context, filter TopFilter) ([]models.Streamer, error) {
+	if s.YouTube.APIKey == "" {
+		return nil, fmt.Errorf("YouTube API key not configured")
+	}
+	// YouTube has no game filter for live search, so search for the category
+	return s.YouTube.GetTopLive(ctx, filter.Category, filter.Language, 50)
+}
+
+func (s *TopService) topKick(ctx context.Context, filter TopFilter) ([]models.Streamer, error) {
+	streams, err := s.Kick.GetLivestreams(ctx, categorySlug(filter.Category), filter.Language, topFetchLimit)
+	if err != nil {
+		return nil, err
+	}
+	return filterCategory(streams, filter.Category), nil
+}
+
+func (s *TopService) topTwitch(ctx context.Context, filter TopFilter) ([]models.Streamer, error) {
+	if !s.Twitch.Configured() {
+		return nil, fmt.Errorf("Twitch API credentials not configured")
+	}
+
+	gameID := ""
+	if filter.Category != "" {
+		game, err := s.Twitch.GetGame(ctx, filter.Category)
+		if err != nil {
+			return nil, err
+		}
+		if game == nil {
+			return []models.Streamer{}, nil
+		}
+		gameID = game.ID
+	}
+	return s.Twitch.GetTopStreams(ctx, gameID, filter.Language, topFetchLimit)
+}
+
+// prune drops expired leaderboards so filter combinations don't pile up.
+// Callers must hold s.mu.
+func (s *TopService) prune() {
+	for key, entry := range s.cache {
+		if time.Since(entry.fetchedAt) >= s.TTL {
+			delete(s.cache, key)
+		}
+	}
+}
+
+// filterCategory keeps the streams in a category, matched by name
+func filterCategory(streams []models.Streamer, category string) []models.Streamer {
+	if category == "" {
+		return streams
+	}
+
+	filtered := make([]models.Streamer, 0, len(streams))
+	for _, st := range streams {
+		if strings.EqualFold(st.Category, category) || categorySlug(st.Category) == categorySlug(category) {
+			filtered = append(filtered, st)
+		}
+	}
+	return filtered
+}
+
+// categorySlug turns a category name into Kick's slug form ("Just Chatting" -> "just-chatting")
+func categorySlug(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+// MatchLanguage reports whether a stream language matches a requested one.
+// Regional variants match their base language ("en-US" matches "en").
+func MatchLanguage(have, want string) bool {
+	have, want = strings.ToLower(have), strings.ToLower(want)
+	return have == want || strings.HasPrefix(have, want+"-") || strings.HasPrefix(have, want+"_")
+}
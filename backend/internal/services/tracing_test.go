@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"multistream/backend/internal/tracing"
+)
+
+// keptSpans is an in-memory exporter that keeps its spans when the
+// provider shuts down, so the test can read them after the final flush
+type keptSpans struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keptSpans) Shutdown(context.Context) error { return nil }
+
+// traceRequests serves GET /api/top through the tracing middleware from a
+// Kick-only leaderboard backed by the Kick stand-in, makes each request and
+// returns the spans exported
+func traceRequests(t *testing.T, requests ...*http.Request) tracetest.SpanStubs {
+	t.Helper()
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	exporter := keptSpans{tracetest.NewInMemoryExporter()}
+	shutdown := tracing.SetupExporter(exporter, 1)
+
+	kick := newFakeKickService(newFakeKick(t))
+	NewResilience(0, time.Millisecond, 5, time.Minute).Wrap("kick", kick.Official.Client)
+	top := NewTopService(nil, kick, nil, time.Minute)
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Get("/api/top", func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := top.Top(r.Context(), TopFilter{Platform: "kick"}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+	})
+
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s = %d: %s", req.Method, req.URL, rec.Code, rec.Body)
+		}
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return exporter.GetSpans()
+}
+
+// spansNamed returns the spans whose name starts with prefix
+func spansNamed(spans tracetest.SpanStubs, prefix string) []tracetest.SpanStub {
+	var found []tracetest.SpanStub
+	for _, span := range spans {
+		if strings.HasPrefix(span.Name, prefix) {
+			found = append(found, span)
+		}
+	}
+	return found
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func checkParent(t *testing.T, child, parent tracetest.SpanStub) {
+	t.Helper()
+	if child.Parent.SpanID() != parent.SpanContext.SpanID() || child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+		t.Errorf("%s is not a child of %s", child.Name, parent.Name)
+	}
+}
+
+func TestTracingSpans(t *testing.T) {
+	spans := traceRequests(t,
+		httptest.NewRequest(http.MethodGet, "/api/top", nil),
+		httptest.NewRequest(http.MethodGet, "/api/top", nil),
+	)
+
+	servers := spansNamed(spans, "GET /api/top")
+	if len(servers) != 2 {
+		t.Fatalf("got %d server spans, want 2 (spans: %v)", len(servers), spanNames(spans))
+	}
+	miss, hit := servers[0], servers[1]
+	for _, server := range servers {
+		if server.SpanKind != trace.SpanKindServer || server.Parent.IsValid() {
+			t.Errorf("server span kind %s, parent %v, want a server root", server.SpanKind, server.Parent.SpanID())
+		}
+		if v, _ := spanAttr(server, "http.route"); v.AsString() != "/api/top" {
+			t.Errorf("http.route = %q", v.AsString())
+		}
+		if v, _ := spanAttr(server, "http.response.status_code"); v.AsInt64() != http.StatusOK {
+			t.Errorf("http.response.status_code = %d", v.AsInt64())
+		}
+	}
+	if miss.SpanContext.TraceID() == hit.SpanContext.TraceID() {
+		t.Error("separate requests share a trace")
+	}
+
+	// Cache lookups: a miss, then a hit, each under its request
+	lookups := spansNamed(spans, "cache.top")
+	if len(lookups) != 2 {
+		t.Fatalf("got %d cache lookups, want 2", len(lookups))
+	}
+	for i, want := range []struct {
+		parent tracetest.SpanStub
+		hit    bool
+	}{{miss, false}, {hit, true}} {
+		checkParent(t, lookups[i], want.parent)
+		if v, ok := spanAttr(lookups[i], "cache.hit"); !ok || v.AsBool() != want.hit {
+			t.Errorf("lookup %d cache.hit = %v, want %v", i, v.AsBool(), want.hit)
+		}
+	}
+
+	// Provider calls: the miss asks Kick, the hit doesn't
+	livestreams := spansNamed(spans, "kick.livestreams")
+	if len(livestreams) != 1 {
+		t.Fatalf("got %d kick.livestreams spans, want 1", len(livestreams))
+	}
+	checkParent(t, livestreams[0], miss)
+
+	official := spansNamed(spans, "kick.official.livestreams")
+	if len(official) != 1 {
+		t.Fatalf("got %d kick.official.livestreams spans, want 1", len(official))
+	}
+	checkParent(t, official[0], livestreams[0])
+
+	calls := append(spansNamed(spans, "HTTP POST "), spansNamed(spans, "HTTP GET ")...)
+	if len(calls) != 2 {
+		t.Fatalf("got %d HTTP spans, want the token and livestreams calls (spans: %v)", len(calls), spanNames(spans))
+	}
+	for _, call := range calls {
+		checkParent(t, call, official[0])
+		if v, _ := spanAttr(call, "provider"); v.AsString() != "kick" {
+			t.Errorf("%s provider = %q, want kick", call.Name, v.AsString())
+		}
+		if v, _ := spanAttr(call, "http.response.status_code"); v.AsInt64() != http.StatusOK {
+			t.Errorf("%s status = %d, want 200", call.Name, v.AsInt64())
+		}
+		if v, ok := spanAttr(call, "http.request.resend_count"); !ok || v.AsInt64() != 0 {
+			t.Errorf("%s resend count = %d, want 0", call.Name, v.AsInt64())
+		}
+	}
+	if !strings.HasSuffix(calls[1].Name, "/public/v1/livestreams") {
+		t.Errorf("provider call span = %q, want the livestreams endpoint", calls[1].Name)
+	}
+}
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	sampled := httptest.NewRequest(http.MethodGet, "/api/top", nil)
+	sampled.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	spans := traceRequests(t, sampled)
+
+	servers := spansNamed(spans, "GET /api/top")
+	if len(servers) != 1 {
+		t.Fatalf("got %d server spans, want 1", len(servers))
+	}
+	server := servers[0]
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != spanID || !server.Parent.IsRemote() {
+		t.Errorf("server span trace %s parent %s, want the incoming trace", server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("%s is in trace %s, want %s", span.Name, span.SpanContext.TraceID(), traceID)
+		}
+	}
+
+	// A caller that didn't sample the trace isn't recorded either
+	unsampled := httptest.NewRequest(http.MethodGet, "/api/top", nil)
+	unsampled.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-00")
+	if spans := traceRequests(t, unsampled); len(spans) != 0 {
+		t.Errorf("exported %v for an unsampled trace", spanNames(spans))
+	}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}
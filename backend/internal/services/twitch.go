@@ -0,0 +1,642 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/tracing"
+)
+
+var twitchLog = logging.Component("twitch")
+
+// TwitchService handles Twitch Helix API interactions using an app access
+// token obtained with the client credentials grant
+type TwitchService struct {
+	ClientID     string
+	ClientSecret string
+	BaseURL      string
+	AuthURL      string
+	Client       *http.Client
+	// Observer, when set, is told about every channel and stream returned
+	Observer StreamObserver
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewTwitchService creates a new Twitch service
+func NewTwitchService(clientID, clientSecret string) *TwitchService {
+	return &TwitchService{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		BaseURL:      "https://api.twitch.tv/helix",
+		AuthURL:      "https://id.twitch.tv/oauth2/token",
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// Configured reports whether Twitch API credentials are set
+func (s *TwitchService) Configured() bool {
+	return s.ClientID != "" && s.ClientSecret != ""
+}
+
+// CheckHealth reports whether an app token can be had. Tokens are cached, so
+// this only reaches Twitch when the cached one expired. Twitch is optional,
+// so without credentials it is disabled rather than failing.
+func (s *TwitchService) CheckHealth(ctx context.Context) (string, string) {
+	if !s.Configured() {
+		return HealthHealthy, "disabled: TWITCH_CLIENT_ID and TWITCH_CLIENT_SECRET not set"
+	}
+	if _, err := s.appToken(ctx, false); err != nil {
+		return HealthUnhealthy, err.Error()
+	}
+	return HealthHealthy, ""
+}
+
+// TwitchUser is a user from the Helix users API
+type TwitchUser struct {
+	ID              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	ProfileImageURL string `json:"profile_image_url"`
+	BroadcasterType string `json:"broadcaster_type"`
+}
+
+// TwitchStream is a live stream from the Helix streams API
+type TwitchStream struct {
+	ID           string   `json:"id"`
+	UserID       string   `json:"user_id"`
+	UserLogin    string   `json:"user_login"`
+	UserName     string   `json:"user_name"`
+	GameID       string   `json:"game_id"`
+	GameName     string   `json:"game_name"`
+	Type         string   `json:"type"`
+	Title        string   `json:"title"`
+	ViewerCount  int      `json:"viewer_count"`
+	StartedAt    string   `json:"started_at"`
+	Language     string   `json:"language"`
+	ThumbnailURL string   `json:"thumbnail_url"`
+	Tags         []string `json:"tags"`
+	IsMature     bool     `json:"is_mature"`
+}
+
+// TwitchScheduleSegment is a broadcast from the Helix schedule API
+type TwitchScheduleSegment struct {
+	ID            string  `json:"id"`
+	StartTime     string  `json:"start_time"`
+	EndTime       string  `json:"end_time"`
+	Title         string  `json:"title"`
+	CanceledUntil *string `json:"canceled_until"`
+	Category      *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"category"`
+}
+
+// TwitchEventSubSubscription is an EventSub subscription as Helix lists it
+type TwitchEventSubSubscription struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	Condition struct {
+		BroadcasterUserID string `json:"broadcaster_user_id"`
+	} `json:"condition"`
+	Transport struct {
+		Method   string `json:"method"`
+		Callback string `json:"callback"`
+	} `json:"transport"`
+}
+
+// TwitchAPIError is returned when Helix answers with a non-2xx status
+type TwitchAPIError struct {
+	StatusCode int
+}
+
+func (e *TwitchAPIError) Error() string {
+	return fmt.Sprintf("Twitch API error: status %d", e.StatusCode)
+}
+
+// GetUser gets a user by login name
+func (s *TwitchService) GetUser(ctx context.Context, login string) (*TwitchUser, error) {
+	var resp struct {
+		Data []TwitchUser `json:"data"`
+	}
+	query := url.Values{"login": {strings.ToLower(strings.TrimSpace(login))}}
+	if err := s.get(ctx, "/users", query, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, login)
+	}
+	return &resp.Data[0], nil
+}
+
+// GetChannelInfo gets the current status of a channel by login name
+func (s *TwitchService) GetChannelInfo(ctx context.Context, login string) (*models.Streamer, error) {
+	user, err := s.GetUser(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data []TwitchStream `json:"data"`
+	}
+	if err := s.get(ctx, "/streams", url.Values{"user_id": {user.ID}}, &resp); err != nil {
+		return nil, err
+	}
+
+	streamer := &models.Streamer{
+		ID:          user.Login,
+		Platform:    "twitch",
+		Username:    user.Login,
+		DisplayName: user.DisplayName,
+		Thumbnail:   user.ProfileImageURL,
+		Title:       user.DisplayName,
+		Verified:    user.BroadcasterType == "partner",
+		IsLive:      false,
+		State:       models.StateOffline,
+		EmbedURL:    twitchEmbedURL(user.Login),
+		ChatURL:     twitchChatURL(user.Login),
+	}
+
+	if len(resp.Data) > 0 {
+		stream := resp.Data[0]
+		streamer.Title = stream.Title
+		streamer.ViewerCount = stream.ViewerCount
+		streamer.Language = stream.Language
+		streamer.Category = stream.GameName
+		streamer.Mature = stream.IsMature
+		streamer.IsLive = stream.Type == "live"
+		streamer.State = models.LiveState(streamer.IsLive)
+		streamer.ActualStartTime = parseTime(stream.StartedAt)
+		streamer.Thumbnail = twitchThumbnail(stream.ThumbnailURL)
+	}
+
+	s.Observer.notify(*streamer)
+	return streamer, nil
+}
+
+// SearchChannels searches channels by name, optionally only live ones. Search
+// results carry no viewer counts or partner status, so those are looked up
+// for the channels found.
+func (s *TwitchService) SearchChannels(ctx context.Context, query string, liveOnly bool, first int) ([]models.Streamer, error) {
+	streamers, _, err := s.SearchChannelsPage(ctx, query, liveOnly, first, "")
+	return streamers, err
+}
+
+// SearchChannelsPage is SearchChannels continuing after a pagination cursor.
+// It also returns the cursor of the next page, which is empty on the last page.
+func (s *TwitchService) SearchChannelsPage(ctx context.Context, query string, liveOnly bool, first int, after string) ([]models.Streamer, string, error) {
+	params := url.Values{"query": {query}, "first": {fmt.Sprintf("%d", min(first, 100))}}
+	if liveOnly {
+		params.Set("live_only", "true")
+	}
+	if after != "" {
+		params.Set("after", after)
+	}
+
+	var resp struct {
+		Data []struct {
+			ID                  string `json:"id"`
+			BroadcasterLogin    string `json:"broadcaster_login"`
+			DisplayName         string `json:"display_name"`
+			BroadcasterLanguage string `json:"broadcaster_language"`
+			GameName            string `json:"game_name"`
+			IsLive              bool   `json:"is_live"`
+			StartedAt           string `json:"started_at"`
+			ThumbnailURL        string `json:"thumbnail_url"`
+			Title               string `json:"title"`
+		} `json:"data"`
+		Pagination struct {
+			Cursor string `json:"cursor"`
+		} `json:"pagination"`
+	}
+	if err := s.get(ctx, "/search/channels", params, &resp); err != nil {
+		return nil, "", err
+	}
+
+	if len(resp.Data) == 0 {
+		return []models.Streamer{}, "", nil
+	}
+
+	ids := url.Values{}
+	for _, ch := range resp.Data {
+		ids.Add("id", ch.ID)
+	}
+
+	var users struct {
+		Data []TwitchUser `json:"data"`
+	}
+	if err := s.get(ctx, "/users", ids, &users); err != nil {
+		twitchLog.WarnContext(ctx, "Failed to look up searched users", "error", err)
+	}
+	partners := make(map[string]bool)
+	for _, u := range users.Data {
+		partners[u.ID] = u.BroadcasterType == "partner"
+	}
+
+	streamIDs := url.Values{"first": {"100"}}
+	for _, ch := range resp.Data {
+		if ch.IsLive {
+			streamIDs.Add("user_id", ch.ID)
+		}
+	}
+	live := make(map[string]TwitchStream)
+	if len(streamIDs["user_id"]) > 0 {
+		var streams struct {
+			Data []TwitchStream `json:"data"`
+		}
+		if err := s.get(ctx, "/streams", streamIDs, &streams); err != nil {
+			twitchLog.WarnContext(ctx, "Failed to look up searched streams", "error", err)
+		}
+		for _, stream := range streams.Data {
+			live[stream.UserID] = stream
+		}
+	}
+
+	streamers := make([]models.Streamer, 0, len(resp.Data))
+	for _, ch := range resp.Data {
+		streamer := models.Streamer{
+			ID:          ch.BroadcasterLogin,
+			Platform:    "twitch",
+			Username:    ch.BroadcasterLogin,
+			DisplayName: ch.DisplayName,
+			Thumbnail:   ch.ThumbnailURL,
+			Title:       ch.Title,
+			Language:    ch.BroadcasterLanguage,
+			Category:    ch.GameName,
+			Verified:    partners[ch.ID],
+			IsLive:      ch.IsLive,
+			State:       models.LiveState(ch.IsLive),
+			EmbedURL:    twitchEmbedURL(ch.BroadcasterLogin),
+			ChatURL:     twitchChatURL(ch.BroadcasterLogin),
+		}
+		if streamer.Title == "" {
+			streamer.Title = ch.DisplayName
+		}
+		if ch.IsLive {
+			streamer.ActualStartTime = parseTime(ch.StartedAt)
+		}
+		if stream, ok := live[ch.ID]; ok {
+			streamer.ViewerCount = stream.ViewerCount
+			streamer.Mature = stream.IsMature
+			streamer.Thumbnail = twitchThumbnail(stream.ThumbnailURL)
+		}
+		streamers = append(streamers, streamer)
+	}
+
+	s.Observer.notify(streamers...)
+	return streamers, resp.Pagination.Cursor, nil
+}
+
+// TwitchGame is a game or category from the Helix games API
+type TwitchGame struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+}
+
+// GetGame gets a game by its exact name. It returns nil without an error
+// when Twitch has no such game.
+func (s *TwitchService) GetGame(ctx context.Context, name string) (*TwitchGame, error) {
+	var resp struct {
+		Data []TwitchGame `json:"data"`
+	}
+	if err := s.get(ctx, "/games", url.Values{"name": {name}}, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+	return &resp.Data[0], nil
+}
+
+// GetTopGames gets the most watched games and categories
+func (s *TwitchService) GetTopGames(ctx context.Context, first int) ([]TwitchGame, error) {
+	var resp struct {
+		Data []TwitchGame `json:"data"`
+	}
+	if err := s.get(ctx, "/games/top", url.Values{"first": {fmt.Sprintf("%d", min(first, 100))}}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// SearchCategories searches games and categories by name
+func (s *TwitchService) SearchCategories(ctx context.Context, query string, first int) ([]TwitchGame, error) {
+	var resp struct {
+		Data []TwitchGame `json:"data"`
+	}
+	params := url.Values{"query": {query}, "first": {fmt.Sprintf("%d", min(first, 100))}}
+	if err := s.get(ctx, "/search/categories", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// GetTopStreams gets the most watched live streams, optionally in a game and
+// language. Helix returns streams sorted by viewers.
+func (s *TwitchService) GetTopStreams(ctx context.Context, gameID, language string, first int) ([]models.Streamer, error) {
+	query := url.Values{"first": {fmt.Sprintf("%d", min(first, 100))}}
+	if gameID != "" {
+		query.Set("game_id", gameID)
+	}
+	if language != "" {
+		query.Set("language", language)
+	}
+
+	var resp struct {
+		Data []TwitchStream `json:"data"`
+	}
+	if err := s.get(ctx, "/streams", query, &resp); err != nil {
+		return nil, err
+	}
+
+	streamers := make([]models.Streamer, 0, len(resp.Data))
+	for _, stream := range resp.Data {
+		streamers = append(streamers, models.Streamer{
+			ID:              stream.UserLogin,
+			Platform:        "twitch",
+			Username:        stream.UserLogin,
+			DisplayName:     stream.UserName,
+			Thumbnail:       twitchThumbnail(stream.ThumbnailURL),
+			Title:           stream.Title,
+			ViewerCount:     stream.ViewerCount,
+			Language:        stream.Language,
+			Category:        stream.GameName,
+			Mature:          stream.IsMature,
+			IsLive:          stream.Type == "live",
+			State:           models.LiveState(stream.Type == "live"),
+			ActualStartTime: parseTime(stream.StartedAt),
+			EmbedURL:        twitchEmbedURL(stream.UserLogin),
+			ChatURL:         twitchChatURL(stream.UserLogin),
+		})
+	}
+
+	s.Observer.notify(streamers...)
+	return streamers, nil
+}
+
+// GetSchedule gets the upcoming scheduled broadcasts of a channel by login name
+func (s *TwitchService) GetSchedule(ctx context.Context, login string) ([]models.Streamer, error) {
+	user, err := s.GetUser(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Segments []TwitchScheduleSegment `json:"segments"`
+		} `json:"data"`
+	}
+	query := url.Values{"broadcaster_id": {user.ID}, "first": {"10"}}
+	if err := s.get(ctx, "/schedule", query, &resp); err != nil {
+		// Channels that never set up a schedule answer with 404
+		var apiErr *TwitchAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return []models.Streamer{}, nil
+		}
+		return nil, err
+	}
+
+	streamers := make([]models.Streamer, 0, len(resp.Data.Segments))
+	for _, segment := range resp.Data.Segments {
+		if segment.CanceledUntil != nil {
+			continue
+		}
+
+		title := segment.Title
+		if title == "" && segment.Category != nil {
+			title = segment.Category.Name
+		}
+		if title == "" {
+			title = user.DisplayName
+		}
+
+		streamers = append(streamers, models.Streamer{
+			ID:                 user.Login,
+			Platform:           "twitch",
+			Username:           user.Login,
+			DisplayName:        user.DisplayName,
+			Thumbnail:          user.ProfileImageURL,
+			Title:              title,
+			IsLive:             false,
+			State:              models.StateScheduled,
+			ScheduledStartTime: parseTime(segment.StartTime),
+			ScheduledEndTime:   parseTime(segment.EndTime),
+			BroadcastID:        segment.ID,
+			EmbedURL:           twitchEmbedURL(user.Login),
+			ChatURL:            twitchChatURL(user.Login),
+		})
+	}
+
+	return streamers, nil
+}
+
+// CreateEventSubSubscription subscribes a webhook callback to an EventSub
+// event for a broadcaster and returns the subscription ID
+func (s *TwitchService) CreateEventSubSubscription(ctx context.Context, eventType, broadcasterID, callback, secret string) (string, error) {
+	body := map[string]interface{}{
+		"type":      eventType,
+		"version":   "1",
+		"condition": map[string]string{"broadcaster_user_id": broadcasterID},
+		"transport": map[string]string{
+			"method":   "webhook",
+			"callback": callback,
+			"secret":   secret,
+		},
+	}
+
+	var resp struct {
+		Data []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	if err := s.do(ctx, "POST", "/eventsub/subscriptions", nil, body, &resp); err != nil {
+		return "", err
+	}
+
+	if len(resp.Data) == 0 {
+		return "", fmt.Errorf("twitch returned no subscription")
+	}
+	return resp.Data[0].ID, nil
+}
+
+// GetEventSubSubscriptions lists the EventSub subscriptions that concern a broadcaster
+func (s *TwitchService) GetEventSubSubscriptions(ctx context.Context, broadcasterID string) ([]TwitchEventSubSubscription, error) {
+	var subs []TwitchEventSubSubscription
+	query := url.Values{"user_id": {broadcasterID}}
+	for {
+		var resp struct {
+			Data       []TwitchEventSubSubscription `json:"data"`
+			Pagination struct {
+				Cursor string `json:"cursor"`
+			} `json:"pagination"`
+		}
+		if err := s.get(ctx, "/eventsub/subscriptions", query, &resp); err != nil {
+			return nil, err
+		}
+		subs = append(subs, resp.Data...)
+		if resp.Pagination.Cursor == "" {
+			return subs, nil
+		}
+		query.Set("after", resp.Pagination.Cursor)
+	}
+}
+
+// DeleteEventSubSubscription removes an EventSub subscription
+func (s *TwitchService) DeleteEventSubSubscription(ctx context.Context, id string) error {
+	return s.do(ctx, "DELETE", "/eventsub/subscriptions", url.Values{"id": {id}}, nil, nil)
+}
+
+// get performs an authenticated GET against the Helix API
+func (s *TwitchService) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return s.do(ctx, "GET", path, query, nil, out)
+}
+
+// do performs an authenticated Helix request, refreshing the app token once
+// if Twitch rejects it
+func (s *TwitchService) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "twitch."+strings.TrimPrefix(path, "/"), attribute.String("http.request.method", method))
+	defer tracing.End(span, &err)
+
+	if !s.Configured() {
+		return fmt.Errorf("Twitch API credentials not configured")
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		token, err := s.appToken(ctx, attempt > 0)
+		if err != nil {
+			return err
+		}
+
+		requestURL := s.BaseURL + path
+		if len(query) > 0 {
+			requestURL += "?" + query.Encode()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Client-Id", s.ClientID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := s.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to call Twitch: %w", err)
+		}
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			twitchLog.InfoContext(ctx, "App token rejected, refreshing")
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			twitchLog.WarnContext(ctx, "API error", "path", path, "status", resp.StatusCode)
+			twitchLog.DebugContext(ctx, "API response", "path", path, "status", resp.StatusCode, "body", logging.Body(respBody))
+			return &TwitchAPIError{StatusCode: resp.StatusCode}
+		}
+
+		if out == nil || len(respBody) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Twitch API error: unauthorized")
+}
+
+// appToken returns a cached app access token, fetching a new one when it is
+// missing, about to expire, or force is set
+func (s *TwitchService) appToken(ctx context.Context, force bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !force && s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	form := url.Values{
+		"client_id":     {s.ClientID},
+		"client_secret": {s.ClientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.AuthURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Twitch app token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get Twitch app token: status %d", resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	s.token = tokenResp.AccessToken
+	// Refresh a minute early so requests in flight don't race the expiry
+	s.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+func twitchEmbedURL(login string) string {
+	return fmt.Sprintf("https://player.twitch.tv/?channel=%s&parent=localhost", login)
+}
+
+func twitchChatURL(login string) string {
+	return fmt.Sprintf("https://www.twitch.tv/embed/%s/chat?parent=localhost", login)
+}
+
+// twitchThumbnail fills in the size placeholders of a stream thumbnail URL
+func twitchThumbnail(template string) string {
+	return strings.NewReplacer("{width}", "640", "{height}", "360").Replace(template)
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTwitch stands in for Twitch: the OAuth token endpoint and the Helix
+// API under /helix
+type fakeTwitch struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// issued counts the app tokens handed out; only the latest is accepted
+	issued int
+	// revoked rejects the latest token until a new one is issued
+	revoked bool
+	// helixStatus, when set, fails every Helix call with it
+	helixStatus int
+	// requests lists the Helix calls made, as "METHOD /path?query"
+	requests []string
+	// eventSubs are the EventSub subscriptions created, by ID
+	eventSubs map[string]map[string]interface{}
+	// deleteStatus, when set, fails deleting EventSub subscriptions with it
+	deleteStatus int
+}
+
+// twitchUsers are the users the fake knows, by login
+var twitchUsers = map[string]map[string]interface{}{
+	"xqc": {
+		"id":                "71092938",
+		"login":             "xqc",
+		"display_name":      "xQc",
+		"profile_image_url": "https://static-cdn.twitch.test/xqc.png",
+		"broadcaster_type":  "partner",
+	},
+	"shroud": {
+		"id":                "37402112",
+		"login":             "shroud",
+		"display_name":      "shroud",
+		"profile_image_url": "https://static-cdn.twitch.test/shroud.png",
+		"broadcaster_type":  "affiliate",
+	},
+}
+
+// twitchLiveStream is the only live stream the fake knows, xqc's
+var twitchLiveStream = map[string]interface{}{
+	"id":            "40952121085",
+	"user_id":       "71092938",
+	"user_login":    "xqc",
+	"user_name":     "xQc",
+	"game_id":       "509658",
+	"game_name":     "Just Chatting",
+	"type":          "live",
+	"title":         "helix title",
+	"viewer_count":  52000,
+	"started_at":    "2026-01-01T18:00:00Z",
+	"language":      "en",
+	"thumbnail_url": "https://static-cdn.twitch.test/live_user_xqc-{width}x{height}.jpg",
+	"is_mature":     true,
+}
+
+func newFakeTwitch(t *testing.T) *fakeTwitch {
+	t.Helper()
+	f := &fakeTwitch{eventSubs: make(map[string]map[string]interface{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth2/token", f.token)
+	mux.HandleFunc("/helix/", f.helix)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeTwitch) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.PostForm.Get("grant_type") != "client_credentials" ||
+		r.PostForm.Get("client_id") != "client-id" || r.PostForm.Get("client_secret") != "client-secret" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.issued++
+	f.revoked = false
+	token := fmt.Sprintf("token-%d", f.issued)
+	f.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"expires_in":   5000000,
+		"token_type":   "bearer",
+	})
+}
+
+func (f *fakeTwitch) helix(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/helix")+"?"+r.URL.RawQuery)
+	valid := token == fmt.Sprintf("token-%d", f.issued) && !f.revoked && r.Header.Get("Client-Id") == "client-id"
+	status := f.helixStatus
+	f.mu.Unlock()
+
+	switch {
+	case !valid:
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case status != 0:
+		w.WriteHeader(status)
+		return
+	}
+
+	query := r.URL.Query()
+	data := []map[string]interface{}{}
+	switch strings.TrimPrefix(r.URL.Path, "/helix") {
+	case "/users":
+		for _, user := range twitchUsers {
+			if slices.Contains(query["login"], user["login"].(string)) || slices.Contains(query["id"], user["id"].(string)) {
+				data = append(data, user)
+			}
+		}
+	case "/streams":
+		ids := query["user_id"]
+		if len(ids) == 0 && (query.Get("game_id") == "" || query.Get("game_id") == "509658") ||
+			slices.Contains(ids, "71092938") {
+			data = append(data, twitchLiveStream)
+		}
+	case "/search/channels":
+		for _, user := range twitchUsers {
+			live := user["login"] == "xqc"
+			if !strings.Contains(user["login"].(string), query.Get("query")) || query.Get("live_only") == "true" && !live {
+				continue
+			}
+			data = append(data, map[string]interface{}{
+				"id":                   user["id"],
+				"broadcaster_login":    user["login"],
+				"display_name":         user["display_name"],
+				"broadcaster_language": "en",
+				"is_live":              live,
+				"thumbnail_url":        user["profile_image_url"],
+			})
+		}
+	case "/schedule":
+		if query.Get("broadcaster_id") != "71092938" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"segments": []map[string]interface{}{
+				{"id": "seg-1", "start_time": "2026-02-01T18:00:00Z", "end_time": "2026-02-01T22:00:00Z", "title": "subathon"},
+				{"id": "seg-2", "start_time": "2026-02-02T18:00:00Z", "title": "", "category": map[string]string{"id": "509658", "name": "Just Chatting"}},
+				{"id": "seg-3", "start_time": "2026-02-03T18:00:00Z", "title": "cancelled", "canceled_until": "2026-02-04T00:00:00Z"},
+			},
+		}})
+		return
+	case "/eventsub/subscriptions":
+		f.eventSub(w, r)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// eventSub creates, lists and deletes EventSub subscriptions. Like Twitch it
+// answers 409 Conflict to a second subscription with the same type,
+// condition and callback.
+func (f *fakeTwitch) eventSub(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case "POST":
+		var req struct {
+			Type      string            `json:"type"`
+			Condition map[string]string `json:"condition"`
+			Transport map[string]string `json:"transport"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, sub := range f.eventSubs {
+			if sub["type"] == req.Type && sub["condition"].(map[string]string)["broadcaster_user_id"] == req.Condition["broadcaster_user_id"] &&
+				sub["transport"].(map[string]string)["callback"] == req.Transport["callback"] {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+		}
+		sub := map[string]interface{}{
+			"id":        fmt.Sprintf("sub-%d", len(f.eventSubs)+1),
+			"type":      req.Type,
+			"status":    "webhook_callback_verification_pending",
+			"condition": req.Condition,
+			"transport": map[string]string{"method": "webhook", "callback": req.Transport["callback"]},
+		}
+		f.eventSubs[sub["id"].(string)] = sub
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{sub}})
+	case "GET":
+		data := []map[string]interface{}{}
+		for _, sub := range f.eventSubs {
+			if sub["condition"].(map[string]string)["broadcaster_user_id"] == r.URL.Query().Get("user_id") {
+				data = append(data, sub)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "pagination": map[string]string{}})
+	case "DELETE":
+		id := r.URL.Query().Get("id")
+		switch {
+		case f.deleteStatus != 0:
+			w.WriteHeader(f.deleteStatus)
+		case f.eventSubs[id] == nil:
+			http.NotFound(w, r)
+		default:
+			delete(f.eventSubs, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func (f *fakeTwitch) stats() (issued int, requests []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.issued, append([]string(nil), f.requests...)
+}
+
+func (f *fakeTwitch) set(fn func(f *fakeTwitch)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+func newFakeTwitchService(f *fakeTwitch) *TwitchService {
+	s := NewTwitchService("client-id", "client-secret")
+	s.BaseURL = f.URL + "/helix"
+	s.AuthURL = f.URL + "/oauth2/token"
+	return s
+}
+
+func TestTwitchGetChannelInfo(t *testing.T) {
+	f := newFakeTwitch(t)
+	s := newFakeTwitchService(f)
+	ctx := context.Background()
+
+	live, err := s.GetChannelInfo(ctx, "xQc")
+	if err != nil {
+		t.Fatalf("GetChannelInfo: %v", err)
+	}
+	if live.Username != "xqc" || live.DisplayName != "xQc" || !live.IsLive || live.State != "live" || !live.Verified {
+		t.Errorf("live channel = %+v", live)
+	}
+	if live.Title != "helix title" || live.ViewerCount != 52000 || live.Language != "en" || live.Category != "Just Chatting" || !live.Mature {
+		t.Errorf("live stream = %+v", live)
+	}
+	if live.Thumbnail != "https://static-cdn.twitch.test/live_user_xqc-640x360.jpg" {
+		t.Errorf("thumbnail = %q, want the size placeholders filled in", live.Thumbnail)
+	}
+	if live.ActualStartTime == nil || !live.ActualStartTime.Equal(time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("started at %v", live.ActualStartTime)
+	}
+
+	offline, err := s.GetChannelInfo(ctx, "shroud")
+	if err != nil {
+		t.Fatalf("GetChannelInfo: %v", err)
+	}
+	if offline.IsLive || offline.State != "offline" || offline.Verified || offline.ViewerCount != 0 {
+		t.Errorf("offline channel = %+v", offline)
+	}
+	if offline.Thumbnail != "https://static-cdn.twitch.test/shroud.png" {
+		t.Errorf("offline thumbnail = %q, want the profile image", offline.Thumbnail)
+	}
+
+	if _, err := s.GetChannelInfo(ctx, "nobody"); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("unknown login = %v, want %v", err, ErrChannelNotFound)
+	}
+}
+
+func TestTwitchCachesToken(t *testing.T) {
+	f := newFakeTwitch(t)
+	s := newFakeTwitchService(f)
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.GetChannelInfo(context.Background(), "xqc"); err != nil {
+			t.Fatalf("GetChannelInfo: %v", err)
+		}
+	}
+
+	if issued, requests := f.stats(); issued != 1 || len(requests) != 6 {
+		t.Errorf("issued %d tokens for %d requests, want 1 for 6", issued, len(requests))
+	}
+}
+
+func TestTwitchRefreshesToken(t *testing.T) {
+	f := newFakeTwitch(t)
+	s := newFakeTwitchService(f)
+	ctx := context.Background()
+
+	if _, err := s.GetUser(ctx, "xqc"); err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+
+	// A token Twitch stops accepting is replaced and the call retried
+	f.set(func(f *fakeTwitch) { f.revoked = true })
+	if _, err := s.GetUser(ctx, "xqc"); err != nil {
+		t.Fatalf("GetUser with a revoked token: %v", err)
+	}
+	if issued, _ := f.stats(); issued != 2 {
+		t.Errorf("issued %d tokens after a rejection, want 2", issued)
+	}
+
+	// A token near expiry is replaced before it is used
+	s.mu.Lock()
+	s.tokenExpiry = time.Now().Add(-time.Second)
+	s.mu.Unlock()
+	if _, err := s.GetUser(ctx, "xqc"); err != nil {
+		t.Fatalf("GetUser with an expired token: %v", err)
+	}
+	if issued, _ := f.stats(); issued != 3 {
+		t.Errorf("issued %d tokens after expiry, want 3", issued)
+	}
+}
+
+func TestTwitchErrors(t *testing.T) {
+	f := newFakeTwitch(t)
+	ctx := context.Background()
+
+	// Helix failures carry their status
+	s := newFakeTwitchService(f)
+	f.set(func(f *fakeTwitch) { f.helixStatus = http.StatusServiceUnavailable })
+	var apiErr *TwitchAPIError
+	if _, err := s.GetUser(ctx, "xqc"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("GetUser during an outage = %v, want a 503 TwitchAPIError", err)
+	}
+
+	// A token that is rejected again after a refresh fails instead of looping
+	f.set(func(f *fakeTwitch) { f.helixStatus = http.StatusUnauthorized })
+	issued, _ := f.stats()
+	if _, err := s.GetUser(ctx, "xqc"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GetUser with a rejected token = %v, want a 401 TwitchAPIError", err)
+	}
+	if after, _ := f.stats(); after != issued+1 {
+		t.Errorf("issued %d tokens for a rejected call, want 1", after-issued)
+	}
+
+	// Bad credentials fail at the token endpoint
+	f.set(func(f *fakeTwitch) { f.helixStatus = 0 })
+	bad := newFakeTwitchService(f)
+	bad.ClientSecret = "wrong"
+	if _, err := bad.GetUser(ctx, "xqc"); err == nil || !strings.Contains(err.Error(), "app token") {
+		t.Errorf("GetUser with bad credentials = %v, want a token error", err)
+	}
+
+	// Without credentials nothing is sent
+	_, before := f.stats()
+	unconfigured := newFakeTwitchService(f)
+	unconfigured.ClientID = ""
+	if _, err := unconfigured.GetUser(ctx, "xqc"); err == nil {
+		t.Error("GetUser without credentials succeeded")
+	}
+	if _, after := f.stats(); len(after) != len(before) {
+		t.Error("an unconfigured service called Helix")
+	}
+}
+
+func TestTwitchGetTopStreams(t *testing.T) {
+	f := newFakeTwitch(t)
+	s := newFakeTwitchService(f)
+
+	streams, err := s.GetTopStreams(context.Background(), "509658", "en", 500)
+	if err != nil {
+		t.Fatalf("GetTopStreams: %v", err)
+	}
+	if len(streams) != 1 || streams[0].Username != "xqc" || streams[0].ViewerCount != 52000 || !streams[0].IsLive {
+		t.Fatalf("streams = %+v", streams)
+	}
+
+	_, requests := f.stats()
+	if last := requests[len(requests)-1]; last != "GET /streams?first=100&game_id=509658&language=en" {
+		t.Errorf("request = %q, want the filters pushed down and first capped at 100", last)
+	}
+}
+
+func TestTwitchSearchChannels(t *testing.T) {
+	f := newFakeTwitch(t)
+	s := newFakeTwitchService(f)
+
+	found, err := s.SearchChannels(context.Background(), "xq", false, 10)
+	if err != nil {
+		t.Fatalf("SearchChannels: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("found %d channels, want 1", len(found))
+	}
+	// Partner status and viewers come from the follow-up lookups
+	if ch := found[0]; !ch.Verified || ch.ViewerCount != 52000 || !ch.Mature || ch.Title != "xQc" {
+		t.Errorf("channel = %+v", ch)
+	}
+}
+
+func TestTwitchGetSchedule(t *testing.T) {
+	f := newFakeTwitch(t)
+	s := newFakeTwitchService(f)
+	ctx := context.Background()
+
+	schedule, err := s.GetSchedule(ctx, "xqc")
+	if err != nil {
+		t.Fatalf("GetSchedule: %v", err)
+	}
+	if len(schedule) != 2 {
+		t.Fatalf("got %d broadcasts, want the 2 not cancelled", len(schedule))
+	}
+	if schedule[0].Title != "subathon" || schedule[0].State != "scheduled" || schedule[0].BroadcastID != "seg-1" {
+		t.Errorf("first broadcast = %+v", schedule[0])
+	}
+	if schedule[1].Title != "Just Chatting" {
+		t.Errorf("untitled broadcast title = %q, want its category", schedule[1].Title)
+	}
+
+	// Channels without a schedule answer 404, which is no broadcasts
+	if schedule, err := s.GetSchedule(ctx, "shroud"); err != nil || len(schedule) != 0 {
+		t.Errorf("GetSchedule without a schedule = %v, %v", schedule, err)
+	}
+}
+
+func TestTwitchCheckHealth(t *testing.T) {
+	if status, message := NewTwitchService("", "").CheckHealth(context.Background()); status != HealthHealthy || !strings.HasPrefix(message, "disabled") {
+		t.Errorf("unconfigured = %s (%s), want healthy and disabled", status, message)
+	}
+
+	f := newFakeTwitch(t)
+	if status, message := newFakeTwitchService(f).CheckHealth(context.Background()); status != HealthHealthy {
+		t.Errorf("configured = %s (%s), want healthy", status, message)
+	}
+
+	s := NewTwitchService("client-id", "wrong-secret")
+	s.AuthURL = f.URL + "/oauth2/token"
+	if status, _ := s.CheckHealth(context.Background()); status != HealthUnhealthy {
+		t.Errorf("rejected credentials = %s, want unhealthy", status)
+	}
+}
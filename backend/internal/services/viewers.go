@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var viewersLog = logging.Component("viewers")
+
+const (
+	// maxHistoryPoints caps how many points a single history query returns
+	maxHistoryPoints = 5000
+
+	// youtubeBatchSize is how many videos fit in one videos.list call
+	youtubeBatchSize = 50
+)
+
+// ViewerRecorder samples the viewer counts of live follows and keeps them
+// as a time series per stream. Raw samples older than RawRetention are
+// rolled up into hourly aggregates, which are kept for HistoryRetention.
+type ViewerRecorder struct {
+	YouTube          *YouTubeService
+	Kick             *KickService
+	Twitch           *TwitchService
+	Follows          *FollowService
+	Interval         time.Duration
+	RawRetention     time.Duration
+	HistoryRetention time.Duration
+
+	mu     sync.RWMutex
+	series map[string]*viewerSeries
+	file   *store.JSONFile
+}
+
+// viewerSeries is the stored time series of a single stream. Samples are
+// kept as [unix seconds, viewers] pairs to keep the file compact.
+type viewerSeries struct {
+	Raw    [][2]int64        `json:"raw,omitempty"`
+	Hourly []viewerAggregate `json:"hourly,omitempty"`
+}
+
+// viewerAggregate summarizes the samples of one hour
+type viewerAggregate struct {
+	Hour  int64 `json:"h"`
+	Sum   int64 `json:"sum"`
+	Count int   `json:"n"`
+	Min   int   `json:"min"`
+	Max   int   `json:"max"`
+}
+
+// NewViewerRecorder creates a new viewer recorder backed by a file in dataDir
+func NewViewerRecorder(youtube *YouTubeService, kick *KickService, twitch *TwitchService, follows *FollowService, dataDir string, interval, rawRetention, historyRetention time.Duration) *ViewerRecorder {
+	r := &ViewerRecorder{
+		YouTube:          youtube,
+		Kick:             kick,
+		Twitch:           twitch,
+		Follows:          follows,
+		Interval:         interval,
+		RawRetention:     rawRetention,
+		HistoryRetention: historyRetention,
+		series:           make(map[string]*viewerSeries),
+		file:             store.NewJSONFile(dataDir, "viewers.json"),
+	}
+	r.file.Compact = true
+
+	if err := r.file.Load(&r.series); err != nil {
+		viewersLog.Error("Failed to load viewer history", "error", err)
+	}
+	if r.series == nil {
+		r.series = make(map[string]*viewerSeries)
+	}
+
+	return r
+}
+
+// seriesKey identifies a stream the way the stream API does: YouTube by
+// video ID, Kick by slug and Twitch by login
+func seriesKey(platform, id string) string {
+	return platform + ":" + id
+}
+
+// Run samples viewer counts every Interval until ctx is done
+func (r *ViewerRecorder) Run(ctx context.Context) {
+	if r.Interval <= 0 {
+		viewersLog.Info("Recording disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	r.Sample(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Sample(ctx)
+		}
+	}
+}
+
+// Sample records the current viewer count of every live follow
+func (r *ViewerRecorder) Sample(ctx context.Context) {
+	live := r.Follows.Live()
+	if len(live) == 0 {
+		r.compact(time.Now())
+		return
+	}
+
+	now := time.Now().Unix()
+	var mu sync.Mutex
+	samples := make(map[string]int)
+	record := func(platform, id string, viewers int) {
+		mu.Lock()
+		samples[seriesKey(platform, id)] = viewers
+		mu.Unlock()
+	}
+
+	// YouTube is sampled in batches, the other platforms channel by channel
+	var videoIDs []string
+	sem := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+
+	for _, f := range live {
+		if f.Platform == "youtube" {
+			videoIDs = append(videoIDs, f.Status.ID)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f models.Follow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var status *models.Streamer
+			var err error
+			switch f.Platform {
+			case "kick":
+				status, err = r.Kick.GetChannelInfo(ctx, f.ChannelID)
+			case "twitch":
+				status, err = r.Twitch.GetChannelInfo(ctx, f.ChannelID)
+			default:
+				return
+			}
+			if err != nil {
+				viewersLog.WarnContext(ctx, "Failed to sample", "follow", f.ID, "error", err)
+				return
+			}
+			if status.IsLive {
+				record(f.Platform, f.ChannelID, status.ViewerCount)
+			}
+		}(f)
+	}
+
+	for start := 0; start < len(videoIDs); start += youtubeBatchSize {
+		end := min(start+youtubeBatchSize, len(videoIDs))
+		videos, err := r.YouTube.GetVideos(ctx, videoIDs[start:end])
+		if err != nil {
+			viewersLog.WarnContext(ctx, "Failed to sample YouTube videos", "error", err)
+			continue
+		}
+		for _, video := range videos {
+			if video.IsLive {
+				record("youtube", video.ID, video.ViewerCount)
+			}
+		}
+	}
+
+	wg.Wait()
+
+	r.mu.Lock()
+	for key, viewers := range samples {
+		s, ok := r.series[key]
+		if !ok {
+			s = &viewerSeries{}
+			r.series[key] = s
+		}
+		s.Raw = append(s.Raw, [2]int64{now, int64(viewers)})
+	}
+	r.mu.Unlock()
+
+	r.compact(time.Now())
+}
+
+// compact rolls raw samples past RawRetention into hourly aggregates, drops
+// aggregates past HistoryRetention and saves the result
+func (r *ViewerRecorder) compact(now time.Time) {
+	rawCutoff := now.Add(-r.RawRetention).Unix()
+	historyCutoff := now.Add(-r.HistoryRetention).Unix()
+
+	r.mu.Lock()
+	for key, s := range r.series {
+		kept := 0
+		for _, sample := range s.Raw {
+			if sample[0] >= rawCutoff {
+				s.Raw[kept] = sample
+				kept++
+				continue
+			}
+			s.addToHour(sample[0], int(sample[1]))
+		}
+		s.Raw = s.Raw[:kept]
+
+		hourly := s.Hourly[:0]
+		for _, agg := range s.Hourly {
+			if agg.Hour >= historyCutoff {
+				hourly = append(hourly, agg)
+			}
+		}
+		s.Hourly = hourly
+
+		if len(s.Raw) == 0 && len(s.Hourly) == 0 {
+			delete(r.series, key)
+		}
+	}
+	err := r.file.Save(r.series)
+	r.mu.Unlock()
+
+	if err != nil {
+		viewersLog.Error("Failed to save viewer history", "error", err)
+	}
+}
+
+// addToHour folds a sample into the aggregate of its hour
+func (s *viewerSeries) addToHour(ts int64, viewers int) {
+	hour := ts - ts%3600
+	i := sort.Search(len(s.Hourly), func(i int) bool { return s.Hourly[i].Hour >= hour })
+	if i == len(s.Hourly) || s.Hourly[i].Hour != hour {
+		s.Hourly = append(s.Hourly, viewerAggregate{})
+		copy(s.Hourly[i+1:], s.Hourly[i:])
+		s.Hourly[i] = viewerAggregate{Hour: hour, Min: viewers, Max: viewers}
+	}
+
+	agg := &s.Hourly[i]
+	agg.Sum += int64(viewers)
+	agg.Count++
+	agg.Min = min(agg.Min, viewers)
+	agg.Max = max(agg.Max, viewers)
+}
+
+// History returns the viewer counts of a stream between from and to,
+// downsampled into step-sized buckets
+func (r *ViewerRecorder) History(platform, id string, from, to time.Time, step time.Duration) ([]models.ViewerPoint, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	if step < time.Second {
+		return nil, fmt.Errorf("step must be at least 1s")
+	}
+	if to.Sub(from)/step > maxHistoryPoints {
+		return nil, fmt.Errorf("step too small: at most %d points per query", maxHistoryPoints)
+	}
+
+	fromUnix, toUnix := from.Unix(), to.Unix()
+	stepSeconds := int64(step / time.Second)
+
+	type bucket struct {
+		start, sum    int64
+		count, lo, hi int
+	}
+	buckets := make(map[int64]*bucket)
+	add := func(ts int64, sum int64, count, lo, hi int) {
+		if ts < fromUnix || ts > toUnix {
+			return
+		}
+		start := ts - (ts-fromUnix)%stepSeconds
+		b, ok := buckets[start]
+		if !ok {
+			b = &bucket{start: start, lo: lo, hi: hi}
+			buckets[start] = b
+		}
+		b.sum += sum
+		b.count += count
+		b.lo = min(b.lo, lo)
+		b.hi = max(b.hi, hi)
+	}
+
+	r.mu.RLock()
+	if s, ok := r.series[seriesKey(platform, id)]; ok {
+		// Rolled-up hours and raw samples never overlap
+		for _, agg := range s.Hourly {
+			add(agg.Hour, agg.Sum, agg.Count, agg.Min, agg.Max)
+		}
+		for _, sample := range s.Raw {
+			add(sample[0], sample[1], 1, int(sample[1]), int(sample[1]))
+		}
+	}
+	r.mu.RUnlock()
+
+	points := make([]models.ViewerPoint, 0, len(buckets))
+	for _, b := range buckets {
+		points = append(points, models.ViewerPoint{
+			Time:    time.Unix(b.start, 0).UTC(),
+			Avg:     int(b.sum / int64(b.count)),
+			Min:     b.lo,
+			Max:     b.hi,
+			Samples: b.count,
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points, nil
+}
@@ -0,0 +1,567 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var webhooksLog = logging.Component("webhooks")
+
+const (
+	// maxStoredDeliveries caps the delivery history kept for inspection and replay
+	maxStoredDeliveries = 500
+
+	// maxRetryBackoff caps the delay between delivery attempts
+	maxRetryBackoff = 30 * time.Minute
+
+	webhookWorkers = 4
+)
+
+// WebhookService delivers stream events to registered webhooks, rendering
+// them as generic JSON or as Discord and Slack messages
+type WebhookService struct {
+	Client      *http.Client
+	Follows     *FollowService
+	FrontendURL string
+	MaxAttempts int
+	Backoff     time.Duration
+	// AllowPrivateNetworks lets webhooks point at loopback and private
+	// addresses, for deployments that only notify services on their own
+	// network. Off by default, since anyone who can reach the API could
+	// otherwise make the backend probe its network.
+	AllowPrivateNetworks bool
+
+	mu         sync.RWMutex
+	webhooks   map[string]*models.Webhook
+	deliveries []*models.Delivery
+	queue      chan string
+
+	webhookFile  *store.JSONFile
+	deliveryFile *store.JSONFile
+}
+
+// NewWebhookService creates a new webhook service backed by files in dataDir.
+// Watch links in chat messages point at frontendURL.
+func NewWebhookService(follows *FollowService, frontendURL, dataDir string, maxAttempts int, backoff time.Duration) *WebhookService {
+	s := &WebhookService{
+		Follows:      follows,
+		FrontendURL:  frontendURL,
+		MaxAttempts:  maxAttempts,
+		Backoff:      backoff,
+		webhooks:     make(map[string]*models.Webhook),
+		queue:        make(chan string, 256),
+		webhookFile:  store.NewJSONFile(dataDir, "webhooks.json"),
+		deliveryFile: store.NewJSONFile(dataDir, "deliveries.json"),
+	}
+
+	s.Client = newPublicClient(10*time.Second, s.checkDial)
+
+	var webhooks []models.Webhook
+	if err := s.webhookFile.Load(&webhooks); err != nil {
+		webhooksLog.Error("Failed to load webhooks", "error", err)
+	}
+	for i := range webhooks {
+		s.webhooks[webhooks[i].ID] = &webhooks[i]
+	}
+
+	if err := s.deliveryFile.Load(&s.deliveries); err != nil {
+		webhooksLog.Error("Failed to load deliveries", "error", err)
+	}
+
+	return s
+}
+
+// Create registers a new webhook
+func (s *WebhookService) Create(req models.WebhookRequest) (*models.Webhook, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if err := s.checkHost(u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = models.WebhookGeneric
+	}
+	if !slices.Contains(models.WebhookKinds, kind) {
+		return nil, fmt.Errorf("invalid kind: must be generic, discord, or slack")
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = models.EventTypes
+	}
+	for _, event := range events {
+		if !slices.Contains(models.EventTypes, event) {
+			return nil, fmt.Errorf("unknown event type: %s", event)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = newID()
+	}
+
+	webhook := &models.Webhook{
+		ID:        newID(),
+		Kind:      kind,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    events,
+		Lists:     mergeLists(nil, req.Lists),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	s.webhooks[webhook.ID] = webhook
+	s.mu.Unlock()
+
+	webhooksLog.Info("Registered webhook", "kind", kind, "id", webhook.ID, "events", events)
+	s.saveWebhooks()
+
+	created := *webhook
+	return &created, nil
+}
+
+// checkHost rejects a webhook host unless it is publicly routable
+func (s *WebhookService) checkHost(host string) error {
+	if s.AllowPrivateNetworks {
+		return nil
+	}
+	return checkPublicHost(host)
+}
+
+// checkDial is the dialer's Control hook; it sees the address actually
+// being connected to
+func (s *WebhookService) checkDial(network, address string, c syscall.RawConn) error {
+	if s.AllowPrivateNetworks {
+		return nil
+	}
+	return checkPublicDial(network, address, c)
+}
+
+// List returns all webhooks with their secrets hidden
+func (s *WebhookService) List() []models.Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := make([]models.Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		w := *webhook
+		w.Secret = ""
+		webhooks = append(webhooks, w)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks
+}
+
+// Get returns a single webhook with its secret hidden
+func (s *WebhookService) Get(id string) (*models.Webhook, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, false
+	}
+	w := *webhook
+	w.Secret = ""
+	return &w, true
+}
+
+// Delete removes a webhook. Its delivery history is kept.
+func (s *WebhookService) Delete(id string) bool {
+	s.mu.Lock()
+	_, ok := s.webhooks[id]
+	delete(s.webhooks, id)
+	s.mu.Unlock()
+
+	if ok {
+		webhooksLog.Info("Deleted webhook", "id", id)
+		s.saveWebhooks()
+	}
+	return ok
+}
+
+// Deliveries returns the deliveries of a webhook, newest first
+func (s *WebhookService) Deliveries(webhookID string) []models.Delivery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := make([]models.Delivery, 0)
+	for i := len(s.deliveries) - 1; i >= 0; i-- {
+		if s.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, *s.deliveries[i])
+		}
+	}
+	return deliveries
+}
+
+// Replay queues a new delivery with the payload of an earlier one
+func (s *WebhookService) Replay(webhookID, deliveryID string) (*models.Delivery, error) {
+	s.mu.Lock()
+
+	if _, ok := s.webhooks[webhookID]; !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	original := s.findDelivery(deliveryID)
+	if original == nil || original.WebhookID != webhookID {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("delivery not found")
+	}
+
+	delivery := s.newDelivery(webhookID, original.EventID, original.EventType, original.Payload)
+	delivery.ReplayOf = original.ID
+	replayed := *delivery
+	s.mu.Unlock()
+
+	s.saveDeliveries()
+	s.enqueue(delivery.ID)
+	return &replayed, nil
+}
+
+// HandleEvent queues deliveries of an event to every webhook subscribed to it
+func (s *WebhookService) HandleEvent(event models.StreamEvent) {
+	s.mu.RLock()
+	var targets []models.Webhook
+	for _, webhook := range s.webhooks {
+		if slices.Contains(webhook.Events, event.Type) && listsOverlap(webhook.Lists, event.Lists) {
+			targets = append(targets, *webhook)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(targets) == 0 {
+		return
+	}
+
+	var queued []string
+	for _, webhook := range targets {
+		payload, err := s.render(webhook, event)
+		if err != nil {
+			webhooksLog.Warn("Failed to render event", "event", event.ID, "webhook", webhook.ID, "error", err)
+			continue
+		}
+
+		s.mu.Lock()
+		delivery := s.newDelivery(webhook.ID, event.ID, event.Type, payload)
+		s.mu.Unlock()
+		queued = append(queued, delivery.ID)
+	}
+
+	s.saveDeliveries()
+	for _, id := range queued {
+		s.enqueue(id)
+	}
+}
+
+// Test queues a delivery of a sample went_live event to a webhook
+func (s *WebhookService) Test(id string) (*models.Delivery, error) {
+	s.mu.RLock()
+	webhook, ok := s.webhooks[id]
+	var target models.Webhook
+	if ok {
+		target = *webhook
+	}
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	event := models.StreamEvent{
+		ID:        newID(),
+		Type:      models.EventWentLive,
+		FollowID:  models.FollowID("kick", "multistream"),
+		Platform:  "kick",
+		ChannelID: "multistream",
+		Streamer: models.Streamer{
+			ID:          "multistream",
+			Platform:    "kick",
+			Username:    "multistream",
+			DisplayName: "MultiStream",
+			Title:       "Test notification from MultiStream",
+			ViewerCount: 1234,
+			IsLive:      true,
+			State:       models.StateLive,
+			EmbedURL:    "https://player.kick.com/multistream",
+		},
+		OccurredAt: time.Now().UTC(),
+	}
+
+	payload, err := s.render(target, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render test event: %w", err)
+	}
+
+	s.mu.Lock()
+	delivery := s.newDelivery(target.ID, event.ID, event.Type, payload)
+	queued := *delivery
+	s.mu.Unlock()
+
+	s.saveDeliveries()
+	s.enqueue(delivery.ID)
+	return &queued, nil
+}
+
+// render builds the body sent to a webhook for an event. Chat sinks link to a
+// multiview of the stream together with other live follows from the same lists.
+func (s *WebhookService) render(webhook models.Webhook, event models.StreamEvent) ([]byte, error) {
+	var others []models.Streamer
+	if s.Follows != nil {
+		others = s.Follows.LiveStreams(webhook.Lists)
+	}
+	watchURL := MultiviewURL(s.FrontendURL, multiviewStreams(event.Streamer, others))
+
+	return renderPayload(webhook.Kind, event, watchURL)
+}
+
+// Run processes queued deliveries until ctx is done. Deliveries left
+// pending by a previous run are queued again on start.
+func (s *WebhookService) Run(ctx context.Context) {
+	s.mu.RLock()
+	var pending []string
+	for _, delivery := range s.deliveries {
+		if delivery.Status == models.DeliveryPending {
+			pending = append(pending, delivery.ID)
+		}
+	}
+	s.mu.RUnlock()
+
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-s.queue:
+					s.attempt(id)
+				}
+			}
+		}()
+	}
+
+	for _, id := range pending {
+		s.enqueue(id)
+	}
+}
+
+// attempt makes one delivery attempt and schedules a retry if it fails
+func (s *WebhookService) attempt(deliveryID string) {
+	s.mu.RLock()
+	delivery := s.findDelivery(deliveryID)
+	if delivery == nil || delivery.Status != models.DeliveryPending {
+		s.mu.RUnlock()
+		return
+	}
+	webhook, ok := s.webhooks[delivery.WebhookID]
+	payload := delivery.Payload
+	eventType := delivery.EventType
+	s.mu.RUnlock()
+
+	if !ok {
+		s.finish(deliveryID, 0, fmt.Errorf("webhook was deleted"), false)
+		return
+	}
+
+	status, err := s.post(webhook, deliveryID, eventType, payload)
+	retryable := err != nil || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+	if err == nil && (status < 200 || status >= 300) {
+		err = fmt.Errorf("unexpected status %d", status)
+	}
+
+	s.finish(deliveryID, status, err, retryable)
+}
+
+// post sends a signed payload to a webhook and returns the response status
+func (s *WebhookService) post(webhook *models.Webhook, deliveryID, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MultiStream-Webhooks/1.0")
+	req.Header.Set("X-MultiStream-Event", eventType)
+	req.Header.Set("X-MultiStream-Delivery", deliveryID)
+	req.Header.Set("X-MultiStream-Timestamp", timestamp)
+	req.Header.Set("X-MultiStream-Signature", "sha256="+signPayload(webhook.Secret, timestamp, payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+// finish records the outcome of an attempt
+func (s *WebhookService) finish(deliveryID string, status int, attemptErr error, retryable bool) {
+	s.mu.Lock()
+	delivery := s.findDelivery(deliveryID)
+	if delivery == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.NextAttemptAt = nil
+
+	var retryIn time.Duration
+
+	switch {
+	case attemptErr == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.Error = ""
+	case retryable && delivery.Attempts < s.MaxAttempts:
+		delivery.Error = attemptErr.Error()
+		retryIn = s.backoff(delivery.Attempts)
+		next := now.Add(retryIn)
+		delivery.NextAttemptAt = &next
+	default:
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = attemptErr.Error()
+	}
+
+	attempts := delivery.Attempts
+	s.mu.Unlock()
+
+	s.saveDeliveries()
+
+	if attemptErr != nil {
+		webhooksLog.Warn("Delivery attempt failed", "delivery", deliveryID, "attempt", attempts, "error", attemptErr)
+	}
+	if retryIn > 0 {
+		time.AfterFunc(retryIn, func() { s.enqueue(deliveryID) })
+	}
+}
+
+// backoff returns the jittered exponential delay before the next attempt
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.Backoff << (attempts - 1)
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	// Up to 20% jitter so retries from many deliveries don't line up
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+func (s *WebhookService) enqueue(deliveryID string) {
+	select {
+	case s.queue <- deliveryID:
+	default:
+		// Queue is full; retry shortly rather than blocking the caller
+		time.AfterFunc(time.Second, func() { s.enqueue(deliveryID) })
+	}
+}
+
+// newDelivery records a pending delivery. Callers must hold s.mu.
+func (s *WebhookService) newDelivery(webhookID, eventID, eventType string, payload []byte) *models.Delivery {
+	delivery := &models.Delivery{
+		ID:        newID(),
+		WebhookID: webhookID,
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   payload,
+		Status:    models.DeliveryPending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	s.deliveries = append(s.deliveries, delivery)
+	if len(s.deliveries) > maxStoredDeliveries {
+		s.deliveries = s.deliveries[len(s.deliveries)-maxStoredDeliveries:]
+	}
+	return delivery
+}
+
+// findDelivery looks up a delivery by ID. Callers must hold s.mu.
+func (s *WebhookService) findDelivery(id string) *models.Delivery {
+	for _, delivery := range s.deliveries {
+		if delivery.ID == id {
+			return delivery
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) saveWebhooks() {
+	s.mu.RLock()
+	webhooks := make([]models.Webhook, 0, len(s.webhooks))
+	for _, webhook := range s.webhooks {
+		webhooks = append(webhooks, *webhook)
+	}
+	s.mu.RUnlock()
+
+	if err := s.webhookFile.Save(webhooks); err != nil {
+		webhooksLog.Error("Failed to save webhooks", "error", err)
+	}
+}
+
+func (s *WebhookService) saveDeliveries() {
+	s.mu.RLock()
+	deliveries := make([]models.Delivery, 0, len(s.deliveries))
+	for _, delivery := range s.deliveries {
+		deliveries = append(deliveries, *delivery)
+	}
+	s.mu.RUnlock()
+
+	if err := s.deliveryFile.Save(deliveries); err != nil {
+		webhooksLog.Error("Failed to save deliveries", "error", err)
+	}
+}
+
+// listsOverlap reports whether lists contains any entry of filter.
+// An empty filter matches everything.
+func listsOverlap(filter, lists []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, list := range filter {
+		if slices.Contains(lists, list) {
+			return true
+		}
+	}
+	return false
+}
+
+// signPayload computes the hex HMAC-SHA256 of "timestamp.payload"
+func signPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
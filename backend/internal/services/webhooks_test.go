@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"multistream/backend/internal/models"
+)
+
+func TestWebhookCreateRejectsInternalAddresses(t *testing.T) {
+	s := NewWebhookService(nil, "http://localhost:3000", t.TempDir(), 3, time.Second)
+
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+		"http://100.100.100.200/hook",
+		"http://198.18.0.1/hook",
+		"http://[::ffff:100.64.0.1]/hook",
+	} {
+		_, err := s.Create(models.WebhookRequest{URL: target})
+		if !errors.Is(err, errPrivateAddress) {
+			t.Errorf("Create(%s) = %v, want %v", target, err, errPrivateAddress)
+		}
+	}
+
+	if _, err := s.Create(models.WebhookRequest{URL: "http://93.184.216.34/hook"}); err != nil {
+		t.Errorf("Create with a public address failed: %v", err)
+	}
+}
+
+func TestWebhookDialRejectsInternalAddresses(t *testing.T) {
+	s := NewWebhookService(nil, "http://localhost:3000", t.TempDir(), 3, time.Second)
+
+	// A host that passed the check at creation may resolve elsewhere later
+	if err := s.checkDial("tcp", "127.0.0.1:443", nil); !errors.Is(err, errPrivateAddress) {
+		t.Errorf("dial to loopback = %v, want %v", err, errPrivateAddress)
+	}
+	if err := s.checkDial("tcp", "[fd00::1]:443", nil); !errors.Is(err, errPrivateAddress) {
+		t.Errorf("dial to unique local = %v, want %v", err, errPrivateAddress)
+	}
+	if err := s.checkDial("tcp", "100.64.12.34:443", nil); !errors.Is(err, errPrivateAddress) {
+		t.Errorf("dial to carrier-grade NAT = %v, want %v", err, errPrivateAddress)
+	}
+	if err := s.checkDial("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dial to public address = %v", err)
+	}
+}
+
+func TestWebhookAllowPrivateNetworks(t *testing.T) {
+	s := NewWebhookService(nil, "http://localhost:3000", t.TempDir(), 3, time.Second)
+	s.AllowPrivateNetworks = true
+
+	if _, err := s.Create(models.WebhookRequest{URL: "http://127.0.0.1:8080/hook"}); err != nil {
+		t.Errorf("Create with private networks allowed failed: %v", err)
+	}
+	if err := s.checkDial("tcp", "127.0.0.1:8080", nil); err != nil {
+		t.Errorf("dial with private networks allowed = %v", err)
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var pushLog = logging.Component("push")
+
+const (
+	// pushMaxBody is the largest encrypted body push services accept
+	pushMaxBody = 4096
+
+	// pushRecordSize is the aes128gcm record size; payloads must fit in one record
+	pushRecordSize = pushMaxBody
+
+	// pushHeaderSize is the aes128gcm header: salt (16), record size (4),
+	// key id length (1) and the uncompressed P-256 key id (65)
+	pushHeaderSize = 16 + 4 + 1 + 65
+
+	// pushMaxPayload is the largest plaintext whose body stays within
+	// pushMaxBody after the header, the 16 byte tag and the padding delimiter
+	pushMaxPayload = pushMaxBody - pushHeaderSize - 16 - 1
+
+	// pushTTL is how long push services keep undelivered notifications
+	pushTTL = 12 * time.Hour
+
+	maxConcurrentPushes = 8
+)
+
+// vapidKeys is the stored VAPID key pair, base64url encoded
+type vapidKeys struct {
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// PushService delivers stream events to browsers with Web Push (RFC 8030),
+// encrypting payloads per RFC 8291 and authenticating with VAPID (RFC 8292)
+type PushService struct {
+	Client      *http.Client
+	Subject     string
+	FrontendURL string
+
+	key       *ecdsa.PrivateKey
+	publicKey string
+
+	mu      sync.RWMutex
+	subs    map[string]*models.PushSubscription
+	subFile *store.JSONFile
+}
+
+// NewPushService creates a new push service. The VAPID key pair is loaded from
+// dataDir, or generated and stored there on first start.
+func NewPushService(subject, frontendURL, dataDir string) (*PushService, error) {
+	s := &PushService{
+		// Endpoints come from whoever subscribes, so they may only be public
+		Client:      newPublicClient(10*time.Second, checkPublicDial),
+		Subject:     subject,
+		FrontendURL: frontendURL,
+		subs:        make(map[string]*models.PushSubscription),
+		subFile:     store.NewJSONFile(dataDir, "push_subscriptions.json"),
+	}
+
+	if err := s.loadKeys(store.NewJSONFile(dataDir, "vapid.json")); err != nil {
+		return nil, err
+	}
+
+	var subs []models.PushSubscription
+	if err := s.subFile.Load(&subs); err != nil {
+		pushLog.Error("Failed to load subscriptions", "error", err)
+	}
+	for i := range subs {
+		if !strings.HasPrefix(subs[i].Endpoint, "https://") {
+			pushLog.Warn("Dropping subscription without an https endpoint", "subscription", subs[i].ID)
+			continue
+		}
+		s.subs[subs[i].ID] = &subs[i]
+	}
+
+	return s, nil
+}
+
+// loadKeys loads the VAPID key pair, generating it if none is stored
+func (s *PushService) loadKeys(file *store.JSONFile) error {
+	var keys vapidKeys
+	if err := file.Load(&keys); err != nil {
+		return err
+	}
+
+	if keys.PrivateKey != "" {
+		d, err := base64.RawURLEncoding.DecodeString(keys.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("invalid VAPID private key: %w", err)
+		}
+		key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d)
+		if err != nil {
+			return fmt.Errorf("invalid VAPID private key: %w", err)
+		}
+		return s.setKey(key)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate VAPID keys: %w", err)
+	}
+	if err := s.setKey(key); err != nil {
+		return err
+	}
+
+	d, err := key.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode VAPID private key: %w", err)
+	}
+
+	pushLog.Info("Generated new VAPID key pair")
+	return file.Save(vapidKeys{
+		PublicKey:  s.publicKey,
+		PrivateKey: base64.RawURLEncoding.EncodeToString(d),
+	})
+}
+
+func (s *PushService) setKey(key *ecdsa.PrivateKey) error {
+	pub, err := key.PublicKey.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to encode VAPID public key: %w", err)
+	}
+	s.key = key
+	s.publicKey = base64.RawURLEncoding.EncodeToString(pub)
+	return nil
+}
+
+// PublicKey returns the VAPID public key the browser subscribes with
+func (s *PushService) PublicKey() string {
+	return s.publicKey
+}
+
+// Subscribe registers a browser push subscription. Subscribing the same
+// endpoint again replaces the earlier subscription. Push services are public
+// https endpoints, so anything else is refused.
+func (s *PushService) Subscribe(req models.PushSubscriptionRequest) (*models.PushSubscription, error) {
+	u, err := url.Parse(req.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("endpoint must be an absolute https URL")
+	}
+	if err := checkPublicHost(u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	if p, err := decodeBase64URL(req.Keys.P256dh); err != nil || len(p) != 65 || p[0] != 0x04 {
+		return nil, fmt.Errorf("keys.p256dh must be an uncompressed P-256 public key")
+	}
+	if a, err := decodeBase64URL(req.Keys.Auth); err != nil || len(a) != 16 {
+		return nil, fmt.Errorf("keys.auth must be a 16 byte secret")
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = []string{models.EventWentLive}
+	}
+	for _, event := range events {
+		if !slices.Contains(models.EventTypes, event) {
+			return nil, fmt.Errorf("unknown event type: %s", event)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(req.Endpoint))
+	sub := &models.PushSubscription{
+		ID:        hex.EncodeToString(sum[:16]),
+		Endpoint:  req.Endpoint,
+		Keys:      req.Keys,
+		Events:    events,
+		Lists:     mergeLists(nil, req.Lists),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	s.subs[sub.ID] = sub
+	s.mu.Unlock()
+
+	pushLog.Info("Registered subscription", "subscription", sub.ID)
+	s.save()
+
+	created := *sub
+	return &created, nil
+}
+
+// Unsubscribe removes a push subscription
+func (s *PushService) Unsubscribe(id string) bool {
+	s.mu.Lock()
+	_, ok := s.subs[id]
+	delete(s.subs, id)
+	s.mu.Unlock()
+
+	if ok {
+		pushLog.Info("Removed subscription", "subscription", id)
+		s.save()
+	}
+	return ok
+}
+
+// HandleEvent sends an event to every subscription interested in it
+func (s *PushService) HandleEvent(event models.StreamEvent) {
+	s.mu.RLock()
+	var targets []models.PushSubscription
+	for _, sub := range s.subs {
+		if slices.Contains(sub.Events, event.Type) && listsOverlap(sub.Lists, event.Lists) {
+			targets = append(targets, *sub)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(targets) == 0 {
+		return
+	}
+
+	payload, err := s.notificationPayload(event)
+	if err != nil {
+		pushLog.Error("Failed to encode event", "event", event.ID, "error", err)
+		return
+	}
+
+	go func() {
+		sem := make(chan struct{}, maxConcurrentPushes)
+		var wg sync.WaitGroup
+
+		for _, sub := range targets {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(sub models.PushSubscription) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.deliver(sub, payload)
+			}(sub)
+		}
+
+		wg.Wait()
+	}()
+}
+
+// notificationPayload renders the JSON shown by the service worker
+func (s *PushService) notificationPayload(event models.StreamEvent) ([]byte, error) {
+	notification := models.PushNotification{
+		Title: eventHeadline(event),
+		Body:  event.Streamer.Title,
+		Icon:  event.Streamer.Thumbnail,
+		URL:   MultiviewURL(s.FrontendURL, []models.Streamer{event.Streamer}),
+		Tag:   event.FollowID,
+		Event: event,
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the raw event rather than fail when it doesn't fit in one record
+	if len(payload) > pushMaxPayload {
+		notification.Event = models.StreamEvent{ID: event.ID, Type: event.Type, FollowID: event.FollowID}
+		payload, err = json.Marshal(notification)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(payload) > pushMaxPayload {
+		return nil, fmt.Errorf("notification payload too large (%d bytes)", len(payload))
+	}
+	return payload, nil
+}
+
+// deliver encrypts and sends one notification. Subscriptions the push
+// service reports as gone are removed.
+func (s *PushService) deliver(sub models.PushSubscription, payload []byte) {
+	body, err := encryptPushPayload(sub.Keys, payload)
+	if err != nil {
+		pushLog.Error("Failed to encrypt", "subscription", sub.ID, "error", err)
+		return
+	}
+
+	authorization, err := s.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		pushLog.Error("Failed to sign VAPID token", "subscription", sub.ID, "error", err)
+		return
+	}
+
+	req, err := http.NewRequest("POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		pushLog.Error("Failed to create request", "subscription", sub.ID, "error", err)
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		pushLog.Warn("Delivery failed", "subscription", sub.ID, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		pushLog.Info("Subscription expired", "subscription", sub.ID, "status", resp.StatusCode)
+		s.Unsubscribe(sub.ID)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		pushLog.Warn("Delivery failed", "subscription", sub.ID, "status", resp.StatusCode)
+	}
+}
+
+// vapidAuthorization builds the VAPID Authorization header for an endpoint
+func (s *PushService) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(pushTTL).Unix(),
+		"sub": s.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// JWS ES256 signatures are the fixed-width concatenation of r and s
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, s.publicKey), nil
+}
+
+func (s *PushService) save() {
+	s.mu.RLock()
+	subs := make([]models.PushSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, *sub)
+	}
+	s.mu.RUnlock()
+
+	if err := s.subFile.Save(subs); err != nil {
+		pushLog.Error("Failed to save subscriptions", "error", err)
+	}
+}
+
+// encryptPushPayload encrypts a payload for a subscription using the
+// aes128gcm content coding from RFC 8291 in a single record
+func encryptPushPayload(keys models.PushKeys, payload []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64URL(keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	authSecret, err := decodeBase64URL(keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth: %w", err)
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+
+	// A fresh application server key pair per message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single, final record: the payload followed by the 0x02 delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	// Header: salt (16) || record size (4) || key id length (1) || key id (as_public)
+	header := make([]byte, 0, pushHeaderSize)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// decodeBase64URL decodes base64url with or without padding, as browsers vary
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
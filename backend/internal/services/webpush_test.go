@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"multistream/backend/internal/models"
+)
+
+// newPushSubscriber creates the browser side of a subscription: its key
+// pair and auth secret
+func newPushSubscriber(t *testing.T) (*ecdh.PrivateKey, models.PushKeys) {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return key, models.PushKeys{
+		P256dh: base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(auth),
+	}
+}
+
+// decryptPushPayload decrypts an aes128gcm body the way a browser does
+func decryptPushPayload(t *testing.T, key *ecdh.PrivateKey, keys models.PushKeys, body []byte) []byte {
+	t.Helper()
+	if len(body) < pushHeaderSize {
+		t.Fatalf("body of %d bytes is shorter than the header", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != pushRecordSize {
+		t.Errorf("record size = %d, want %d", rs, pushRecordSize)
+	}
+	idlen := int(body[20])
+	asPublicBytes := body[21 : 21+idlen]
+	ciphertext := body[21+idlen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("key id is not a P-256 key: %v", err)
+	}
+	ecdhSecret, err := key.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, _ := decodeBase64URL(keys.Auth)
+
+	keyInfo := "WebPush: info\x00" + string(key.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, auth, keyInfo, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prk, _ := hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+
+	// The only record is the last one, so it ends with the 0x02 delimiter
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatal("record doesn't end with the last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestPushPayloadRoundTrip(t *testing.T) {
+	key, keys := newPushSubscriber(t)
+
+	for _, size := range []int{0, 100, pushMaxPayload} {
+		payload := bytes.Repeat([]byte("x"), size)
+		body, err := encryptPushPayload(keys, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(body) > pushMaxBody {
+			t.Errorf("%d byte payload encrypts to %d bytes, over the %d byte limit", size, len(body), pushMaxBody)
+		}
+		if got := decryptPushPayload(t, key, keys, body); !bytes.Equal(got, payload) {
+			t.Errorf("%d byte payload decrypted to %d bytes", size, len(got))
+		}
+	}
+
+	// Each message has its own salt and server key
+	a, _ := encryptPushPayload(keys, []byte("same"))
+	b, _ := encryptPushPayload(keys, []byte("same"))
+	if bytes.Equal(a[:pushHeaderSize], b[:pushHeaderSize]) {
+		t.Error("two messages share a header")
+	}
+}
+
+func TestPushNotificationPayloadFits(t *testing.T) {
+	s, err := NewPushService("mailto:ops@example.com", "http://localhost:3000", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := models.StreamEvent{
+		ID:       "evt-1",
+		Type:     models.EventWentLive,
+		FollowID: "kick:xqc",
+		Streamer: models.Streamer{
+			Platform:    "kick",
+			Username:    "xqc",
+			DisplayName: "xQc",
+			Title:       strings.Repeat("long title ", 120),
+		},
+	}
+	payload, err := s.notificationPayload(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload) > pushMaxPayload {
+		t.Fatalf("payload is %d bytes, over %d", len(payload), pushMaxPayload)
+	}
+
+	var notification models.PushNotification
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		t.Fatal(err)
+	}
+	if notification.Event.ID != event.ID || notification.Event.Streamer.Title != "" {
+		t.Errorf("oversized event = %+v, want it reduced to its IDs", notification.Event)
+	}
+}
+
+func TestPushVAPIDAuthorization(t *testing.T) {
+	s, err := NewPushService("mailto:ops@example.com", "http://localhost:3000", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorization, err := s.vapidAuthorization("https://push.example.com:8443/wpush/v2/abc?x=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, publicKey, ok := strings.Cut(strings.TrimPrefix(authorization, "vapid t="), ", k=")
+	if !strings.HasPrefix(authorization, "vapid t=") || !ok {
+		t.Fatalf("Authorization = %q, want vapid t=..., k=...", authorization)
+	}
+	if publicKey != s.PublicKey() {
+		t.Errorf("k = %q, want the VAPID public key %q", publicKey, s.PublicKey())
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token has %d parts, want 3", len(parts))
+	}
+
+	var header struct{ Typ, Alg string }
+	decodeJWTPart(t, parts[0], &header)
+	if header.Typ != "JWT" || header.Alg != "ES256" {
+		t.Errorf("header = %+v, want an ES256 JWT", header)
+	}
+
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	decodeJWTPart(t, parts[1], &claims)
+	if claims.Aud != "https://push.example.com:8443" {
+		t.Errorf("aud = %q, want the endpoint's origin", claims.Aud)
+	}
+	if claims.Sub != "mailto:ops@example.com" {
+		t.Errorf("sub = %q", claims.Sub)
+	}
+	// Push services refuse tokens valid for more than 24 hours
+	if exp := time.Unix(claims.Exp, 0); !exp.After(time.Now()) || exp.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("exp = %v, want within the next 24 hours", exp)
+	}
+
+	// The signature verifies with the advertised key
+	rawKey, err := base64.RawURLEncoding.DecodeString(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.ParseUncompressedPublicKey(s.key.Curve, rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("signature is %d bytes (%v), want 64", len(signature), err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, sig := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(key, digest[:], r, sig) {
+		t.Error("signature doesn't verify with the VAPID public key")
+	}
+}
+
+func decodeJWTPart(t *testing.T, part string, v interface{}) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatalf("invalid base64url: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+}
+
+func TestPushSubscribeRejectsInternalEndpoints(t *testing.T) {
+	s, err := NewPushService("mailto:ops@example.com", "http://localhost:3000", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, keys := newPushSubscriber(t)
+
+	if _, err := s.Subscribe(models.PushSubscriptionRequest{Endpoint: "http://93.184.216.34/push", Keys: keys}); err == nil {
+		t.Error("Subscribe accepted a plain http endpoint")
+	}
+	for _, endpoint := range []string{
+		"https://127.0.0.1/push",
+		"https://localhost/push",
+		"https://10.1.2.3/push",
+		"https://169.254.169.254/latest/meta-data",
+		"https://100.64.0.1/push",
+		"https://[::1]/push",
+	} {
+		_, err := s.Subscribe(models.PushSubscriptionRequest{Endpoint: endpoint, Keys: keys})
+		if !errors.Is(err, errPrivateAddress) {
+			t.Errorf("Subscribe(%s) = %v, want %v", endpoint, err, errPrivateAddress)
+		}
+	}
+
+	if _, err := s.Subscribe(models.PushSubscriptionRequest{Endpoint: "https://93.184.216.34/push", Keys: keys}); err != nil {
+		t.Errorf("Subscribe with a public endpoint failed: %v", err)
+	}
+}
+
+func TestPushDeliveryRefusesInternalAddresses(t *testing.T) {
+	s, err := NewPushService("mailto:ops@example.com", "http://localhost:3000", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, keys := newPushSubscriber(t)
+
+	// A stored endpoint whose host now resolves to the backend's own network
+	reached := false
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer srv.Close()
+
+	s.deliver(models.PushSubscription{ID: "sub", Endpoint: srv.URL + "/push", Keys: keys}, []byte(`{}`))
+	if reached {
+		t.Error("delivery reached a loopback endpoint")
+	}
+}
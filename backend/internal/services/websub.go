@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+var websubLog = logging.Component("websub")
+
+const (
+	// youtubeFeedURL is the Atom feed topic YouTube publishes uploads and broadcasts to
+	youtubeFeedURL = "https://www.youtube.com/xml/feeds/videos.xml?channel_id="
+
+	// webSubSyncInterval is how often subscriptions are reconciled with follows
+	webSubSyncInterval = time.Minute
+
+	// webSubPendingTimeout is how long to wait for the hub's verification
+	// request before asking again
+	webSubPendingTimeout = 10 * time.Minute
+)
+
+// WebSub subscription states
+const (
+	WebSubPending       = "pending"
+	WebSubActive        = "active"
+	WebSubUnsubscribing = "unsubscribing"
+)
+
+// WebSubSubscription is a subscription to a YouTube channel's feed on the hub
+type WebSubSubscription struct {
+	ChannelID   string     `json:"channelId"`
+	Secret      string     `json:"-"`
+	State       string     `json:"state"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastPushAt  *time.Time `json:"lastPushAt,omitempty"`
+}
+
+// storedWebSubSubscription keeps the secret, which is hidden from the API
+type storedWebSubSubscription struct {
+	WebSubSubscription
+	Secret string `json:"secret"`
+}
+
+// WebSubService subscribes followed YouTube channels to the WebSub
+// (PubSubHubbub) hub so new broadcasts are pushed instead of polled
+type WebSubService struct {
+	YouTube       *YouTubeService
+	Follows       *FollowService
+	Client        *http.Client
+	HubURL        string
+	CallbackURL   string
+	LeaseDuration time.Duration
+
+	// Schedule, when set, is told about pushed videos so newly scheduled
+	// broadcasts show up without searching for them
+	Schedule *ScheduleService
+
+	mu   sync.RWMutex
+	subs map[string]*WebSubSubscription
+	file *store.JSONFile
+}
+
+// NewWebSubService creates a new WebSub service. Hub callbacks are sent to
+// callbackURL followed by the channel ID.
+func NewWebSubService(youtube *YouTubeService, follows *FollowService, hubURL, callbackURL, dataDir string, lease time.Duration) *WebSubService {
+	s := &WebSubService{
+		YouTube:       youtube,
+		Follows:       follows,
+		HubURL:        hubURL,
+		CallbackURL:   strings.TrimRight(callbackURL, "/"),
+		LeaseDuration: lease,
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		subs: make(map[string]*WebSubSubscription),
+		file: store.NewJSONFile(dataDir, "websub.json"),
+	}
+
+	var saved []storedWebSubSubscription
+	if err := s.file.Load(&saved); err != nil {
+		websubLog.Error("Failed to load subscriptions", "error", err)
+	}
+	for _, sub := range saved {
+		sub.WebSubSubscription.Secret = sub.Secret
+		entry := sub.WebSubSubscription
+		s.subs[sub.ChannelID] = &entry
+	}
+
+	return s
+}
+
+// IsActive reports whether pushes are currently being received for a follow
+func (s *WebSubService) IsActive(f models.Follow) bool {
+	if f.Platform != "youtube" {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subs[f.ChannelID]
+	return ok && sub.State == WebSubActive && sub.ExpiresAt != nil && time.Now().Before(*sub.ExpiresAt)
+}
+
+// Subscriptions returns all hub subscriptions
+func (s *WebSubService) Subscriptions() []WebSubSubscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := make([]WebSubSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, *sub)
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ChannelID < subs[j].ChannelID
+	})
+	return subs
+}
+
+// Run keeps hub subscriptions in line with followed YouTube channels,
+// renewing leases before they expire, until ctx is done
+func (s *WebSubService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webSubSyncInterval)
+	defer ticker.Stop()
+
+	s.Sync()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sync()
+		}
+	}
+}
+
+// Sync subscribes new YouTube follows, renews expiring leases and
+// unsubscribes channels that are no longer followed
+func (s *WebSubService) Sync() {
+	followed := make(map[string]bool)
+	for _, f := range s.Follows.List() {
+		if f.Platform == "youtube" {
+			followed[f.ChannelID] = true
+		}
+	}
+
+	now := time.Now()
+	// Renew once less than a fifth of the lease remains
+	renewBefore := s.LeaseDuration / 5
+
+	var subscribe, unsubscribe []string
+
+	s.mu.Lock()
+	for channelID := range followed {
+		sub, ok := s.subs[channelID]
+		switch {
+		case !ok:
+			subscribe = append(subscribe, channelID)
+		case sub.State == WebSubPending || sub.State == WebSubUnsubscribing:
+			if now.Sub(sub.RequestedAt) > webSubPendingTimeout {
+				subscribe = append(subscribe, channelID)
+			}
+		case sub.ExpiresAt == nil || sub.ExpiresAt.Sub(now) < renewBefore:
+			if now.Sub(sub.RequestedAt) > webSubPendingTimeout {
+				subscribe = append(subscribe, channelID)
+			}
+		}
+	}
+	for channelID, sub := range s.subs {
+		if followed[channelID] {
+			continue
+		}
+		if sub.State != WebSubUnsubscribing || now.Sub(sub.RequestedAt) > webSubPendingTimeout {
+			unsubscribe = append(unsubscribe, channelID)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, channelID := range subscribe {
+		if err := s.request(channelID, "subscribe"); err != nil {
+			websubLog.Warn("Failed to subscribe", "channel", channelID, "error", err)
+		}
+	}
+	for _, channelID := range unsubscribe {
+		if err := s.request(channelID, "unsubscribe"); err != nil {
+			websubLog.Warn("Failed to unsubscribe", "channel", channelID, "error", err)
+		}
+	}
+
+	if len(subscribe) > 0 || len(unsubscribe) > 0 {
+		s.save()
+	}
+}
+
+// request asks the hub to subscribe or unsubscribe a channel's feed.
+// The hub confirms asynchronously by calling Verify.
+func (s *WebSubService) request(channelID, mode string) error {
+	s.mu.Lock()
+	sub, ok := s.subs[channelID]
+	if !ok {
+		sub = &WebSubSubscription{ChannelID: channelID}
+		s.subs[channelID] = sub
+	}
+	// The secret is kept across renewals so pushes signed before the hub
+	// verifies the renewal still validate
+	if mode == "subscribe" {
+		if sub.Secret == "" {
+			sub.Secret = newID()
+		}
+		// Renewing an active lease keeps it active until the hub confirms
+		if sub.State != WebSubActive {
+			sub.State = WebSubPending
+		}
+	} else {
+		sub.State = WebSubUnsubscribing
+	}
+	sub.RequestedAt = time.Now().UTC()
+	secret := sub.Secret
+	s.mu.Unlock()
+
+	form := url.Values{
+		"hub.callback": {s.CallbackURL + "/" + url.PathEscape(channelID)},
+		"hub.mode":     {mode},
+		"hub.topic":    {youtubeFeedURL + channelID},
+		"hub.verify":   {"async"},
+	}
+	if mode == "subscribe" {
+		form.Set("hub.lease_seconds", strconv.Itoa(int(s.LeaseDuration.Seconds())))
+		form.Set("hub.secret", secret)
+	}
+
+	resp, err := s.Client.PostForm(s.HubURL, form)
+	if err != nil {
+		return fmt.Errorf("hub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		websubLog.Debug("Hub response", "channel", channelID, "status", resp.StatusCode, "body", logging.Body(body))
+		return fmt.Errorf("hub returned status %d", resp.StatusCode)
+	}
+
+	websubLog.Info("Requested", "mode", mode, "channel", channelID)
+	return nil
+}
+
+// Verify answers the hub's verification of intent. It returns the challenge
+// to echo back, or false when the request doesn't match a pending request.
+func (s *WebSubService) Verify(channelID string, query url.Values) (string, bool) {
+	mode := query.Get("hub.mode")
+	topic := query.Get("hub.topic")
+	challenge := query.Get("hub.challenge")
+
+	if topic != youtubeFeedURL+channelID {
+		return "", false
+	}
+
+	// A denial is a notice rather than a challenge, so there is nothing to
+	// echo. Anyone can send one, so it only counts while a subscription is
+	// waiting for the hub to answer.
+	if mode == "denied" {
+		s.mu.Lock()
+		sub, ok := s.subs[channelID]
+		if !ok || sub.State != WebSubPending {
+			s.mu.Unlock()
+			return "", false
+		}
+		delete(s.subs, channelID)
+		s.mu.Unlock()
+
+		websubLog.Warn("Hub denied subscription", "channel", channelID, "reason", query.Get("hub.reason"))
+		s.save()
+		return "", true
+	}
+
+	if challenge == "" {
+		return "", false
+	}
+
+	s.mu.Lock()
+	sub, ok := s.subs[channelID]
+
+	switch {
+	case mode == "subscribe" && ok && sub.State != WebSubUnsubscribing:
+		lease, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || lease <= 0 {
+			lease = int(s.LeaseDuration.Seconds())
+		}
+		expires := time.Now().UTC().Add(time.Duration(lease) * time.Second)
+		sub.State = WebSubActive
+		sub.ExpiresAt = &expires
+	case mode == "unsubscribe" && (!ok || sub.State == WebSubUnsubscribing):
+		delete(s.subs, channelID)
+	default:
+		s.mu.Unlock()
+		return "", false
+	}
+	s.mu.Unlock()
+
+	websubLog.Info("Verified", "mode", mode, "channel", channelID)
+	s.save()
+	return challenge, true
+}
+
+// youtubeFeed is the Atom document the hub pushes for YouTube channels
+type youtubeFeed struct {
+	Entries []struct {
+		VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+		ChannelID string `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+		Title     string `xml:"title"`
+	} `xml:"entry"`
+}
+
+// Notify handles content pushed by the hub. Notifications with a missing or
+// invalid signature are ignored. Each video in the feed is checked with
+// GetStreamInfo and live broadcasts update the follow's status.
+func (s *WebSubService) Notify(ctx context.Context, channelID string, signature string, body []byte) error {
+	s.mu.RLock()
+	sub, ok := s.subs[channelID]
+	var secret string
+	if ok {
+		secret = sub.Secret
+	}
+	s.mu.RUnlock()
+
+	if !ok || sub.State == WebSubUnsubscribing {
+		return fmt.Errorf("no subscription for %s", channelID)
+	}
+	if !verifyHubSignature(secret, signature, body) {
+		return fmt.Errorf("invalid signature for %s", channelID)
+	}
+
+	var feed youtubeFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	now := time.Now().UTC()
+	s.mu.Lock()
+	if sub, ok := s.subs[channelID]; ok {
+		sub.LastPushAt = &now
+	}
+	s.mu.Unlock()
+
+	for _, entry := range feed.Entries {
+		if entry.VideoID == "" || entry.ChannelID != channelID {
+			continue
+		}
+		websubLog.InfoContext(ctx, "Push", "channel", channelID, "video", entry.VideoID)
+		go s.checkVideo(context.WithoutCancel(ctx), channelID, entry.VideoID)
+	}
+	return nil
+}
+
+// checkVideo turns a pushed video into a live status check
+func (s *WebSubService) checkVideo(ctx context.Context, channelID, videoID string) {
+	video, err := s.YouTube.GetStreamInfo(ctx, videoID)
+	if err != nil {
+		websubLog.WarnContext(ctx, "Failed to check video", "video", videoID, "error", err)
+		return
+	}
+
+	f, ok := s.Follows.Get("youtube", channelID)
+	if !ok {
+		return
+	}
+
+	if s.Schedule != nil {
+		s.Schedule.Observe(video)
+	}
+
+	// Uploads and edits to other videos don't change a live channel's status
+	switch {
+	case video.IsLive:
+		s.Follows.UpdateStatus("youtube", channelID, video)
+	case f.IsLive() && f.Status.ID == videoID:
+		s.Follows.UpdateStatus("youtube", channelID, &models.Streamer{
+			ID:          channelID,
+			Platform:    "youtube",
+			Username:    channelID,
+			DisplayName: f.DisplayName,
+			IsLive:      false,
+			State:       models.StateOffline,
+		})
+	}
+}
+
+func (s *WebSubService) save() {
+	s.mu.RLock()
+	subs := make([]storedWebSubSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, storedWebSubSubscription{WebSubSubscription: *sub, Secret: sub.Secret})
+	}
+	s.mu.RUnlock()
+
+	if err := s.file.Save(subs); err != nil {
+		websubLog.Error("Failed to save subscriptions", "error", err)
+	}
+}
+
+// verifyHubSignature checks an X-Hub-Signature header ("sha1=<hex>" or another
+// supported algorithm) against the HMAC of the body
+func verifyHubSignature(secret, header string, body []byte) bool {
+	algorithm, signature, ok := strings.Cut(header, "=")
+	if !ok || secret == "" {
+		return false
+	}
+
+	var h func() hash.Hash
+	switch algorithm {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
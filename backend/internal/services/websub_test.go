@@ -0,0 +1,451 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"multistream/backend/internal/models"
+	"multistream/backend/internal/store"
+)
+
+const (
+	webSubTestChannel = "UCtestchannel000000000000"
+	webSubTestLease   = 24 * time.Hour
+)
+
+// hubStandIn plays the WebSub hub: it accepts subscription requests and,
+// like a real hub, verifies intent by calling the callback with a challenge
+type hubStandIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []url.Values
+	verified chan hubVerification
+}
+
+type hubVerification struct {
+	mode   string
+	status int
+	echoed bool
+}
+
+func newHubStandIn(t *testing.T) *hubStandIn {
+	t.Helper()
+	hub := &hubStandIn{verified: make(chan hubVerification, 8)}
+	hub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		form := r.PostForm
+		hub.mu.Lock()
+		hub.requests = append(hub.requests, form)
+		hub.mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+		go hub.verify(form)
+	}))
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+// verify sends the verification of intent for a subscription request
+func (h *hubStandIn) verify(form url.Values) {
+	challenge := newID()
+	query := url.Values{
+		"hub.mode":      {form.Get("hub.mode")},
+		"hub.topic":     {form.Get("hub.topic")},
+		"hub.challenge": {challenge},
+	}
+	if form.Get("hub.mode") == "subscribe" {
+		query.Set("hub.lease_seconds", form.Get("hub.lease_seconds"))
+	}
+
+	result := hubVerification{mode: form.Get("hub.mode")}
+	resp, err := http.Get(form.Get("hub.callback") + "?" + query.Encode())
+	if err == nil {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		result.status = resp.StatusCode
+		result.echoed = string(body) == challenge
+	}
+	h.verified <- result
+}
+
+func (h *hubStandIn) Requests() []url.Values {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]url.Values(nil), h.requests...)
+}
+
+func (h *hubStandIn) waitVerified(t *testing.T) hubVerification {
+	t.Helper()
+	select {
+	case v := <-h.verified:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("hub never verified the subscription")
+	}
+	return hubVerification{}
+}
+
+// webSubTest wires a WebSub service to a hub stand-in, a callback server
+// and a fake YouTube Data API, with one followed YouTube channel
+type webSubTest struct {
+	service *WebSubService
+	follows *FollowService
+	hub     *hubStandIn
+	events  chan models.StreamEvent
+}
+
+func newWebSubTest(t *testing.T) *webSubTest {
+	t.Helper()
+	dataDir := t.TempDir()
+
+	err := store.NewJSONFile(dataDir, "follows.json").Save([]models.Follow{{
+		ID:          models.FollowID("youtube", webSubTestChannel),
+		Platform:    "youtube",
+		ChannelID:   webSubTestChannel,
+		DisplayName: "Test Channel",
+		AddedAt:     time.Now().UTC(),
+		Status: &models.Streamer{
+			ID:       webSubTestChannel,
+			Platform: "youtube",
+			Username: webSubTestChannel,
+			State:    models.StateOffline,
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	youtube := NewYouTubeService("test-key")
+	youtubeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/videos" || r.Header.Get("X-Goog-Api-Key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `{"items": [{
+			"id": %q,
+			"snippet": {"channelId": %q, "channelTitle": "Test Channel", "title": "Live now", "liveBroadcastContent": "live"},
+			"liveStreamingDetails": {"concurrentViewers": "420", "actualStartTime": "2026-01-01T00:00:00Z"}
+		}]}`, r.URL.Query().Get("id"), webSubTestChannel)
+	}))
+	t.Cleanup(youtubeAPI.Close)
+	youtube.BaseURL = youtubeAPI.URL
+
+	bus := NewEventBus()
+	events := make(chan models.StreamEvent, 8)
+	bus.Subscribe(func(e models.StreamEvent) { events <- e })
+	follows := NewFollowService(youtube, nil, nil, bus, dataDir, 0)
+
+	var service *WebSubService
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		channel := path.Base(r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			challenge, ok := service.Verify(channel, r.URL.Query())
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, challenge)
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			if err := service.Notify(r.Context(), channel, r.Header.Get("X-Hub-Signature"), body); err != nil {
+				w.WriteHeader(http.StatusForbidden)
+			}
+		}
+	}))
+	t.Cleanup(callback.Close)
+
+	hub := newHubStandIn(t)
+	service = NewWebSubService(youtube, follows, hub.URL, callback.URL+"/websub/youtube", dataDir, webSubTestLease)
+
+	return &webSubTest{service: service, follows: follows, hub: hub, events: events}
+}
+
+func (w *webSubTest) subscription(t *testing.T) WebSubSubscription {
+	t.Helper()
+	w.service.mu.RLock()
+	defer w.service.mu.RUnlock()
+	sub, ok := w.service.subs[webSubTestChannel]
+	if !ok {
+		t.Fatal("no subscription for the followed channel")
+	}
+	return *sub
+}
+
+// subscribe runs the handshake and returns the active subscription
+func (w *webSubTest) subscribe(t *testing.T) WebSubSubscription {
+	t.Helper()
+	w.service.Sync()
+	if v := w.hub.waitVerified(t); v.status != http.StatusOK || !v.echoed {
+		t.Fatalf("verification = %+v, want the challenge echoed with 200", v)
+	}
+	return w.subscription(t)
+}
+
+// waitSaved waits until the follow saved on disk is live on videoID, so the
+// push has finished writing before the test removes the data directory
+func (w *webSubTest) waitSaved(t *testing.T, videoID string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var follows []models.Follow
+		w.follows.file.Load(&follows)
+		if len(follows) == 1 && follows[0].IsLive() && follows[0].Status.ID == videoID {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("follow never went live on %s", videoID)
+}
+
+func hubSignature(algorithm func() hash.Hash, name, secret string, body []byte) string {
+	mac := hmac.New(algorithm, []byte(secret))
+	mac.Write(body)
+	return name + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webSubTestFeed(videoID string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <yt:videoId>` + videoID + `</yt:videoId>
+    <yt:channelId>` + webSubTestChannel + `</yt:channelId>
+    <title>Live now</title>
+  </entry>
+</feed>`)
+}
+
+func TestWebSubSubscribeHandshake(t *testing.T) {
+	w := newWebSubTest(t)
+	sub := w.subscribe(t)
+
+	requests := w.hub.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("hub got %d requests, want 1", len(requests))
+	}
+	form := requests[0]
+	checks := map[string]string{
+		"hub.mode":          "subscribe",
+		"hub.topic":         youtubeFeedURL + webSubTestChannel,
+		"hub.verify":        "async",
+		"hub.lease_seconds": fmt.Sprint(int(webSubTestLease.Seconds())),
+	}
+	for key, want := range checks {
+		if got := form.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if !strings.HasSuffix(form.Get("hub.callback"), "/websub/youtube/"+webSubTestChannel) {
+		t.Errorf("hub.callback = %q", form.Get("hub.callback"))
+	}
+	if form.Get("hub.secret") == "" || form.Get("hub.secret") != sub.Secret {
+		t.Errorf("hub.secret = %q, want the subscription's secret", form.Get("hub.secret"))
+	}
+
+	if sub.State != WebSubActive {
+		t.Errorf("state = %s, want %s", sub.State, WebSubActive)
+	}
+	if sub.ExpiresAt == nil || time.Until(*sub.ExpiresAt) < webSubTestLease-time.Minute {
+		t.Errorf("expires at %v, want about %s from now", sub.ExpiresAt, webSubTestLease)
+	}
+	f, _ := w.follows.Get("youtube", webSubTestChannel)
+	if !w.service.IsActive(*f) {
+		t.Error("IsActive = false after verification")
+	}
+}
+
+func TestWebSubVerifyRejectsUnexpectedRequests(t *testing.T) {
+	w := newWebSubTest(t)
+	w.subscribe(t)
+
+	verify := func(mode, topic string) bool {
+		_, ok := w.service.Verify(webSubTestChannel, url.Values{
+			"hub.mode":      {mode},
+			"hub.topic":     {topic},
+			"hub.challenge": {"challenge"},
+		})
+		return ok
+	}
+
+	if verify("subscribe", youtubeFeedURL+"UCsomeoneelse") {
+		t.Error("accepted a challenge for another topic")
+	}
+	if verify("unsubscribe", youtubeFeedURL+webSubTestChannel) {
+		t.Error("accepted an unsubscribe nobody asked for")
+	}
+	if verify("denied", youtubeFeedURL+webSubTestChannel) {
+		t.Error("accepted a denial for an active subscription")
+	}
+	if sub := w.subscription(t); sub.State != WebSubActive {
+		t.Errorf("state = %s after rejected requests, want %s", sub.State, WebSubActive)
+	}
+}
+
+func TestWebSubDeniedWhilePending(t *testing.T) {
+	w := newWebSubTest(t)
+	// The hub stand-in verifies every request, so ask for one without it
+	quiet := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer quiet.Close()
+	w.service.HubURL = quiet.URL
+
+	w.service.Sync()
+	if sub := w.subscription(t); sub.State != WebSubPending {
+		t.Fatalf("state = %s, want %s", sub.State, WebSubPending)
+	}
+
+	_, ok := w.service.Verify(webSubTestChannel, url.Values{
+		"hub.mode":   {"denied"},
+		"hub.topic":  {youtubeFeedURL + webSubTestChannel},
+		"hub.reason": {"not allowed"},
+	})
+	if !ok {
+		t.Fatal("denial of a pending subscription was rejected")
+	}
+	if len(w.service.Subscriptions()) != 0 {
+		t.Error("denied subscription was kept")
+	}
+}
+
+func TestWebSubSyncRenewsLeases(t *testing.T) {
+	w := newWebSubTest(t)
+	first := w.subscribe(t)
+
+	// A fresh lease is left alone
+	w.service.Sync()
+	if n := len(w.hub.Requests()); n != 1 {
+		t.Fatalf("hub got %d requests for a fresh lease, want 1", n)
+	}
+
+	// Less than a fifth of the lease left: renew it
+	w.service.mu.Lock()
+	sub := w.service.subs[webSubTestChannel]
+	expires := time.Now().Add(webSubTestLease / 10)
+	sub.ExpiresAt = &expires
+	sub.RequestedAt = time.Now().Add(-webSubPendingTimeout - time.Minute)
+	w.service.mu.Unlock()
+
+	w.service.Sync()
+	if sub := w.subscription(t); sub.State != WebSubActive {
+		t.Errorf("state = %s while renewing, want %s", sub.State, WebSubActive)
+	}
+	if v := w.hub.waitVerified(t); v.status != http.StatusOK || !v.echoed {
+		t.Fatalf("renewal verification = %+v", v)
+	}
+
+	requests := w.hub.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("hub got %d requests, want a renewal", len(requests))
+	}
+	if requests[1].Get("hub.mode") != "subscribe" || requests[1].Get("hub.secret") != first.Secret {
+		t.Errorf("renewal = %v, want subscribe with the same secret", requests[1])
+	}
+	if renewed := w.subscription(t); renewed.ExpiresAt == nil || time.Until(*renewed.ExpiresAt) < webSubTestLease-time.Minute {
+		t.Errorf("lease expires at %v after renewal", renewed.ExpiresAt)
+	}
+}
+
+func TestWebSubRejectsBadSignatures(t *testing.T) {
+	w := newWebSubTest(t)
+	sub := w.subscribe(t)
+	body := webSubTestFeed("video00001")
+
+	for _, tc := range []struct {
+		name      string
+		signature string
+	}{
+		{"missing", ""},
+		{"sha1 wrong secret", hubSignature(sha1.New, "sha1", "wrong", body)},
+		{"sha256 wrong secret", hubSignature(sha256.New, "sha256", "wrong", body)},
+		{"sha1 other body", hubSignature(sha1.New, "sha1", sub.Secret, []byte("other"))},
+		{"sha256 other body", hubSignature(sha256.New, "sha256", sub.Secret, []byte("other"))},
+		{"sha256 digest labelled sha1", "sha1=" + strings.TrimPrefix(hubSignature(sha256.New, "sha256", sub.Secret, body), "sha256=")},
+		{"not hex", "sha256=zz"},
+		{"unknown algorithm", "md5=" + strings.TrimPrefix(hubSignature(sha1.New, "sha1", sub.Secret, body), "sha1=")},
+		{"no algorithm", strings.TrimPrefix(hubSignature(sha1.New, "sha1", sub.Secret, body), "sha1=")},
+	} {
+		if err := w.service.Notify(context.Background(), webSubTestChannel, tc.signature, body); err == nil {
+			t.Errorf("%s: push accepted", tc.name)
+		}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		algorithm func() hash.Hash
+		videoID   string
+	}{
+		{"sha1", sha1.New, "video00002"},
+		{"sha256", sha256.New, "video00003"},
+	} {
+		body := webSubTestFeed(tc.videoID)
+		signature := hubSignature(tc.algorithm, tc.name, sub.Secret, body)
+		if err := w.service.Notify(context.Background(), webSubTestChannel, signature, body); err != nil {
+			t.Errorf("valid %s push rejected: %v", tc.name, err)
+			continue
+		}
+		w.waitSaved(t, tc.videoID)
+	}
+}
+
+func TestWebSubNotifyUpdatesFollow(t *testing.T) {
+	w := newWebSubTest(t)
+	sub := w.subscribe(t)
+
+	body := webSubTestFeed("livevideo01")
+	resp, err := http.Post(w.service.CallbackURL+"/"+webSubTestChannel, "application/atom+xml", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("unsigned push got status %d, want it refused", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, w.service.CallbackURL+"/"+webSubTestChannel, strings.NewReader(string(body)))
+	req.Header.Set("X-Hub-Signature", hubSignature(sha256.New, "sha256", sub.Secret, body))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("signed push got status %d", resp.StatusCode)
+	}
+
+	select {
+	case event := <-w.events:
+		if event.Type != models.EventWentLive || event.Streamer.ID != "livevideo01" {
+			t.Errorf("event = %s for %s, want went_live for livevideo01", event.Type, event.Streamer.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("push did not update the follow")
+	}
+
+	f, _ := w.follows.Get("youtube", webSubTestChannel)
+	if !f.IsLive() || f.Status.ID != "livevideo01" || f.Status.ViewerCount != 420 {
+		t.Errorf("follow status = %+v, want live on livevideo01 with 420 viewers", f.Status)
+	}
+	if sub := w.subscription(t); sub.LastPushAt == nil {
+		t.Error("last push time not recorded")
+	}
+	w.waitSaved(t, "livevideo01")
+}
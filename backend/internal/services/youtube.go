@@ -1,20 +1,31 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"multistream/backend/internal/logging"
+	"multistream/backend/internal/metrics"
 	"multistream/backend/internal/models"
+	"multistream/backend/internal/tracing"
 )
 
+var youtubeLog = logging.Component("youtube")
+
 // YouTubeService handles YouTube Data API interactions
 type YouTubeService struct {
 	APIKey  string
 	BaseURL string
+	Client  *http.Client
+	// Observer, when set, is told about every video and channel returned
+	Observer StreamObserver
 }
 
 // NewYouTubeService creates a new YouTube service
@@ -22,9 +33,64 @@ func NewYouTubeService(apiKey string) *YouTubeService {
 	return &YouTubeService{
 		APIKey:  apiKey,
 		BaseURL: "https://www.googleapis.com/youtube/v3",
+		Client: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: youtubeQuotaTransport{next: http.DefaultTransport},
+		},
 	}
 }
 
+// youtubeQuotaCosts is how many quota units a call to each Data API
+// endpoint costs
+var youtubeQuotaCosts = map[string]int{
+	"search":          100,
+	"videos":          1,
+	"channels":        1,
+	"videoCategories": 1,
+}
+
+// youtubeQuotaTransport counts the quota spent by every request YouTube
+// answers. It sits under the retrying transport, so each retry is counted
+// too: YouTube charges for every attempt, not for the logical call.
+type youtubeQuotaTransport struct {
+	next http.RoundTripper
+}
+
+func (t youtubeQuotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		endpoint := path.Base(req.URL.Path)
+		if cost, ok := youtubeQuotaCosts[endpoint]; ok {
+			metrics.YouTubeQuota.WithLabelValues(endpoint).Add(float64(cost))
+		}
+	}
+	return resp, err
+}
+
+// get calls a Data API endpoint
+func (s *YouTubeService) get(ctx context.Context, endpoint, requestURL string) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(ctx, "youtube."+endpoint)
+	defer tracing.End(span, &err)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// The key goes in a header so request URLs, which end up in errors
+	// and logs, don't carry it
+	req.Header.Set("X-Goog-Api-Key", s.APIKey)
+	return s.Client.Do(req)
+}
+
+// CheckHealth reports whether an API key is set. YouTube isn't called, since
+// every call costs quota; failing calls show in the circuit breaker instead.
+func (s *YouTubeService) CheckHealth(context.Context) (string, string) {
+	if s.APIKey == "" {
+		return HealthUnhealthy, "YOUTUBE_API_KEY not set"
+	}
+	return HealthHealthy, ""
+}
+
 // YouTubeSearchResponse represents the API response
 type YouTubeSearchResponse struct {
 	Items []struct {
@@ -52,60 +118,167 @@ type YouTubeSearchResponse struct {
 			} `json:"thumbnails"`
 		} `json:"snippet"`
 	} `json:"items"`
-	Error *struct {
+	NextPageToken string `json:"nextPageToken"`
+	Error         *struct {
 		Code    int    `json:"code"`
 		Message string `json:"message"`
 	} `json:"error"`
 }
 
 // SearchVideos searches for ALL videos on YouTube (live, past streams, regular videos)
-func (s *YouTubeService) SearchVideos(query string, maxResults int) ([]models.Streamer, error) {
+func (s *YouTubeService) SearchVideos(ctx context.Context, query string, maxResults int) ([]models.Streamer, error) {
+	return s.searchVideos(ctx, YouTubeSearchOptions{Query: query, MaxResults: maxResults})
+}
+
+// SearchLive searches for broadcasts that are live right now
+func (s *YouTubeService) SearchLive(ctx context.Context, query string, maxResults int) ([]models.Streamer, error) {
+	return s.searchVideos(ctx, YouTubeSearchOptions{Query: query, EventType: "live", MaxResults: maxResults})
+}
+
+// SearchUpcoming searches for scheduled broadcasts and premieres
+func (s *YouTubeService) SearchUpcoming(ctx context.Context, query string, maxResults int) ([]models.Streamer, error) {
+	return s.searchVideos(ctx, YouTubeSearchOptions{Query: query, EventType: "upcoming", MaxResults: maxResults})
+}
+
+// GetUpcomingStreams gets the scheduled broadcasts of a channel
+func (s *YouTubeService) GetUpcomingStreams(ctx context.Context, channelID string) ([]models.Streamer, error) {
+	return s.searchVideos(ctx, YouTubeSearchOptions{EventType: "upcoming", ChannelID: channelID, MaxResults: 10})
+}
+
+// GetTopLive gets the most watched live broadcasts, optionally about a topic
+// (such as a game name) and in a language
+func (s *YouTubeService) GetTopLive(ctx context.Context, topic, language string, maxResults int) ([]models.Streamer, error) {
+	return s.GetTopLiveInCategory(ctx, topic, "", language, maxResults)
+}
+
+// GetTopLiveInCategory is GetTopLive restricted to a video category
+func (s *YouTubeService) GetTopLiveInCategory(ctx context.Context, topic, videoCategoryID, language string, maxResults int) ([]models.Streamer, error) {
+	streamers, err := s.searchVideos(ctx, YouTubeSearchOptions{
+		Query:           topic,
+		EventType:       "live",
+		Order:           "viewCount",
+		Language:        language,
+		VideoCategoryID: videoCategoryID,
+		MaxResults:      maxResults,
+	})
+	if err != nil || language == "" {
+		return streamers, err
+	}
+
+	// relevanceLanguage only biases results, so drop other known languages
+	filtered := make([]models.Streamer, 0, len(streamers))
+	for _, st := range streamers {
+		if st.Language == "" || MatchLanguage(st.Language, language) {
+			filtered = append(filtered, st)
+		}
+	}
+	return filtered, nil
+}
+
+// YouTubeSearchOptions narrows a video search
+type YouTubeSearchOptions struct {
+	Query string
+	// EventType restricts results to live, upcoming or completed broadcasts
+	EventType string
+	ChannelID string
+	// Order is relevance (the default), date, viewCount or rating
+	Order string
+	// Language biases results toward an ISO 639-1 language
+	Language string
+	// VideoCategoryID restricts results to a video category, such as 20 (Gaming)
+	VideoCategoryID string
+	// SafeSearch is none, moderate (YouTube's default) or strict
+	SafeSearch string
+	MaxResults int
+	// PageToken continues a previous search
+	PageToken string
+}
+
+// Search runs a video search with the given options
+func (s *YouTubeService) Search(ctx context.Context, opts YouTubeSearchOptions) ([]models.Streamer, error) {
+	return s.searchVideos(ctx, opts)
+}
+
+// SearchPage runs a video search and also returns the token of the next
+// page, which is empty on the last page
+func (s *YouTubeService) SearchPage(ctx context.Context, opts YouTubeSearchOptions) ([]models.Streamer, string, error) {
+	return s.searchPage(ctx, opts)
+}
+
+// searchVideos runs a video search and fills in broadcast details
+func (s *YouTubeService) searchVideos(ctx context.Context, opts YouTubeSearchOptions) ([]models.Streamer, error) {
+	streamers, _, err := s.searchPage(ctx, opts)
+	return streamers, err
+}
+
+// searchPage runs one page of a video search and fills in broadcast details
+func (s *YouTubeService) searchPage(ctx context.Context, opts YouTubeSearchOptions) ([]models.Streamer, string, error) {
 	if s.APIKey == "" {
-		return nil, fmt.Errorf("YouTube API key not configured")
+		return nil, "", fmt.Errorf("YouTube API key not configured")
+	}
+
+	order := opts.Order
+	if order == "" {
+		order = "relevance"
 	}
 
-	// Search for ALL videos (no eventType filter)
 	searchURL := fmt.Sprintf(
-		"%s/search?part=snippet&type=video&q=%s&maxResults=%d&order=relevance&key=%s",
+		"%s/search?part=snippet&type=video&q=%s&maxResults=%d&order=%s",
 		s.BaseURL,
-		url.QueryEscape(query),
-		maxResults,
-		s.APIKey,
+		url.QueryEscape(opts.Query),
+		opts.MaxResults,
+		url.QueryEscape(order),
 	)
+	if opts.EventType != "" {
+		searchURL += "&eventType=" + url.QueryEscape(opts.EventType)
+	}
+	if opts.ChannelID != "" {
+		searchURL += "&channelId=" + url.QueryEscape(opts.ChannelID)
+	}
+	if opts.Language != "" {
+		searchURL += "&relevanceLanguage=" + url.QueryEscape(opts.Language)
+	}
+	if opts.VideoCategoryID != "" {
+		searchURL += "&videoCategoryId=" + url.QueryEscape(opts.VideoCategoryID)
+	}
+	if opts.SafeSearch != "" {
+		searchURL += "&safeSearch=" + url.QueryEscape(opts.SafeSearch)
+	}
+	if opts.PageToken != "" {
+		searchURL += "&pageToken=" + url.QueryEscape(opts.PageToken)
+	}
 
-	log.Printf("[YouTube] Searching for: %s", query)
+	youtubeLog.DebugContext(ctx, "Searching", "query", opts.Query, "page_token", opts.PageToken)
 
-	resp, err := http.Get(searchURL)
+	resp, err := s.get(ctx, "search", searchURL)
 	if err != nil {
-		log.Printf("[YouTube] HTTP error: %v", err)
-		return nil, fmt.Errorf("failed to search YouTube: %w", err)
+		return nil, "", fmt.Errorf("failed to search YouTube: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Read the body for logging
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("[YouTube] API error (status %d): %s", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("YouTube API error: status %d - check API key and quota", resp.StatusCode)
+		youtubeLog.WarnContext(ctx, "API error", "endpoint", "search", "status", resp.StatusCode)
+		youtubeLog.DebugContext(ctx, "API response", "endpoint", "search", "status", resp.StatusCode, "body", logging.Body(body))
+		return nil, "", fmt.Errorf("YouTube API error: status %d - check API key and quota", resp.StatusCode)
 	}
 
 	var searchResp YouTubeSearchResponse
 	if err := json.Unmarshal(body, &searchResp); err != nil {
-		log.Printf("[YouTube] JSON decode error: %v", err)
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Check for API error in response
 	if searchResp.Error != nil {
-		log.Printf("[YouTube] API returned error: %s", searchResp.Error.Message)
-		return nil, fmt.Errorf("YouTube API: %s", searchResp.Error.Message)
+		youtubeLog.WarnContext(ctx, "API returned error", "endpoint", "search", "message", searchResp.Error.Message)
+		return nil, "", fmt.Errorf("YouTube API: %s", searchResp.Error.Message)
 	}
 
-	log.Printf("[YouTube] Found %d results", len(searchResp.Items))
+	youtubeLog.DebugContext(ctx, "Search results", "count", len(searchResp.Items))
 
 	// Convert to our Streamer model
 	streamers := make([]models.Streamer, 0, len(searchResp.Items))
@@ -115,7 +288,7 @@ func (s *YouTubeService) SearchVideos(query string, maxResults int) ([]models.St
 			thumbnail = item.Snippet.Thumbnails.Medium.URL
 		}
 
-		isLive := item.Snippet.LiveBroadcastContent == "live"
+		state := youtubeState(item.Snippet.LiveBroadcastContent, "", "")
 
 		streamers = append(streamers, models.Streamer{
 			ID:          item.ID.VideoID,
@@ -124,39 +297,97 @@ func (s *YouTubeService) SearchVideos(query string, maxResults int) ([]models.St
 			DisplayName: item.Snippet.ChannelTitle,
 			Title:       item.Snippet.Title,
 			Thumbnail:   thumbnail,
-			IsLive:      isLive,
+			IsLive:      state == models.StateLive,
+			State:       state,
 			EmbedURL:    fmt.Sprintf("https://www.youtube.com/embed/%s?autoplay=1", item.ID.VideoID),
 			ChatURL:     fmt.Sprintf("https://www.youtube.com/live_chat?v=%s&embed_domain=localhost", item.ID.VideoID),
 		})
 	}
 
-	return streamers, nil
+	s.addVideoDetails(ctx, streamers)
+	return streamers, searchResp.NextPageToken, nil
+}
+
+// addVideoDetails fills in viewer counts, broadcast times and states that
+// search results don't carry, using one batched videos call (1 quota unit)
+func (s *YouTubeService) addVideoDetails(ctx context.Context, streamers []models.Streamer) {
+	if len(streamers) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(streamers))
+	for _, st := range streamers {
+		ids = append(ids, st.ID)
+	}
+
+	videos, err := s.GetVideos(ctx, ids)
+	if err != nil {
+		youtubeLog.WarnContext(ctx, "Failed to fetch video details", "error", err)
+		return
+	}
+
+	byID := make(map[string]models.Streamer, len(videos))
+	for _, v := range videos {
+		byID[v.ID] = v
+	}
+
+	for i := range streamers {
+		v, ok := byID[streamers[i].ID]
+		if !ok {
+			continue
+		}
+		streamers[i].ViewerCount = v.ViewerCount
+		streamers[i].IsLive = v.IsLive
+		streamers[i].State = v.State
+		streamers[i].ScheduledStartTime = v.ScheduledStartTime
+		streamers[i].ScheduledEndTime = v.ScheduledEndTime
+		streamers[i].ActualStartTime = v.ActualStartTime
+		streamers[i].Language = v.Language
+		streamers[i].Mature = v.Mature
+	}
 }
 
 // SearchLiveStreams calls SearchVideos (for backward compatibility)
-func (s *YouTubeService) SearchLiveStreams(query string, maxResults int) ([]models.Streamer, error) {
-	return s.SearchVideos(query, maxResults)
+func (s *YouTubeService) SearchLiveStreams(ctx context.Context, query string, maxResults int) ([]models.Streamer, error) {
+	return s.SearchVideos(ctx, query, maxResults)
 }
 
 // GetStreamInfo gets detailed info for a specific video
-func (s *YouTubeService) GetStreamInfo(videoID string) (*models.Streamer, error) {
+func (s *YouTubeService) GetStreamInfo(ctx context.Context, videoID string) (*models.Streamer, error) {
+	videos, err := s.GetVideos(ctx, []string{videoID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(videos) == 0 {
+		return nil, fmt.Errorf("video not found")
+	}
+
+	return &videos[0], nil
+}
+
+// GetVideos gets detailed info for up to 50 videos in a single call
+func (s *YouTubeService) GetVideos(ctx context.Context, videoIDs []string) ([]models.Streamer, error) {
 	if s.APIKey == "" {
 		return nil, fmt.Errorf("YouTube API key not configured")
 	}
 
 	videoURL := fmt.Sprintf(
-		"%s/videos?part=snippet,liveStreamingDetails,statistics&id=%s&key=%s",
+		"%s/videos?part=snippet,liveStreamingDetails,statistics,contentDetails&id=%s",
 		s.BaseURL,
-		videoID,
-		s.APIKey,
+		url.QueryEscape(strings.Join(videoIDs, ",")),
 	)
 
-	resp, err := http.Get(videoURL)
+	resp, err := s.get(ctx, "videos", videoURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get video info: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("YouTube API error: status %d - check API key and quota", resp.StatusCode)
+	}
+
 	var videoResp struct {
 		Items []struct {
 			ID      string `json:"id"`
@@ -165,6 +396,8 @@ func (s *YouTubeService) GetStreamInfo(videoID string) (*models.Streamer, error)
 				ChannelTitle         string `json:"channelTitle"`
 				Title                string `json:"title"`
 				LiveBroadcastContent string `json:"liveBroadcastContent"`
+				DefaultAudioLanguage string `json:"defaultAudioLanguage"`
+				DefaultLanguage      string `json:"defaultLanguage"`
 				Thumbnails           struct {
 					High struct {
 						URL string `json:"url"`
@@ -174,8 +407,17 @@ func (s *YouTubeService) GetStreamInfo(videoID string) (*models.Streamer, error)
 			Statistics struct {
 				ViewCount string `json:"viewCount"`
 			} `json:"statistics"`
+			ContentDetails struct {
+				ContentRating struct {
+					YTRating string `json:"ytRating"`
+				} `json:"contentRating"`
+			} `json:"contentDetails"`
 			LiveStreamingDetails struct {
-				ConcurrentViewers string `json:"concurrentViewers"`
+				ConcurrentViewers  string `json:"concurrentViewers"`
+				ScheduledStartTime string `json:"scheduledStartTime"`
+				ScheduledEndTime   string `json:"scheduledEndTime"`
+				ActualStartTime    string `json:"actualStartTime"`
+				ActualEndTime      string `json:"actualEndTime"`
 			} `json:"liveStreamingDetails"`
 		} `json:"items"`
 	}
@@ -184,31 +426,204 @@ func (s *YouTubeService) GetStreamInfo(videoID string) (*models.Streamer, error)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if len(videoResp.Items) == 0 {
-		return nil, fmt.Errorf("video not found")
+	videos := make([]models.Streamer, 0, len(videoResp.Items))
+	for _, item := range videoResp.Items {
+		viewerCount := 0
+
+		if item.LiveStreamingDetails.ConcurrentViewers != "" {
+			fmt.Sscanf(item.LiveStreamingDetails.ConcurrentViewers, "%d", &viewerCount)
+		} else if item.Statistics.ViewCount != "" {
+			fmt.Sscanf(item.Statistics.ViewCount, "%d", &viewerCount)
+		}
+
+		details := item.LiveStreamingDetails
+		state := youtubeState(item.Snippet.LiveBroadcastContent, details.ActualStartTime, details.ActualEndTime)
+
+		language := item.Snippet.DefaultAudioLanguage
+		if language == "" {
+			language = item.Snippet.DefaultLanguage
+		}
+
+		videos = append(videos, models.Streamer{
+			ID:                 item.ID,
+			Platform:           "youtube",
+			Username:           item.Snippet.ChannelID,
+			DisplayName:        item.Snippet.ChannelTitle,
+			Title:              item.Snippet.Title,
+			Thumbnail:          item.Snippet.Thumbnails.High.URL,
+			ViewerCount:        viewerCount,
+			Language:           language,
+			Mature:             item.ContentDetails.ContentRating.YTRating == "ytAgeRestricted",
+			IsLive:             state == models.StateLive,
+			State:              state,
+			ScheduledStartTime: parseTime(details.ScheduledStartTime),
+			ScheduledEndTime:   parseTime(details.ScheduledEndTime),
+			ActualStartTime:    parseTime(details.ActualStartTime),
+			EmbedURL:           fmt.Sprintf("https://www.youtube.com/embed/%s?autoplay=1", item.ID),
+			ChatURL:            fmt.Sprintf("https://www.youtube.com/live_chat?v=%s&embed_domain=localhost", item.ID),
+		})
+	}
+
+	s.Observer.notify(videos...)
+	return videos, nil
+}
+
+// GetChannel gets a channel by its ID (UC...) or its @handle
+func (s *YouTubeService) GetChannel(ctx context.Context, channel string) (*models.Streamer, error) {
+	if s.APIKey == "" {
+		return nil, fmt.Errorf("YouTube API key not configured")
+	}
+
+	filter := "id=" + url.QueryEscape(channel)
+	if strings.HasPrefix(channel, "@") {
+		filter = "forHandle=" + url.QueryEscape(channel)
+	}
+
+	channelURL := fmt.Sprintf(
+		"%s/channels?part=snippet&%s",
+		s.BaseURL,
+		filter,
+	)
+
+	resp, err := s.get(ctx, "channels", channelURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("YouTube API error: status %d - check API key and quota", resp.StatusCode)
 	}
 
-	item := videoResp.Items[0]
-	viewerCount := 0
+	var channelResp struct {
+		Items []struct {
+			ID      string `json:"id"`
+			Snippet struct {
+				Title      string `json:"title"`
+				CustomURL  string `json:"customUrl"`
+				Thumbnails struct {
+					High struct {
+						URL string `json:"url"`
+					} `json:"high"`
+				} `json:"thumbnails"`
+			} `json:"snippet"`
+		} `json:"items"`
+	}
 
-	if item.LiveStreamingDetails.ConcurrentViewers != "" {
-		fmt.Sscanf(item.LiveStreamingDetails.ConcurrentViewers, "%d", &viewerCount)
-	} else if item.Statistics.ViewCount != "" {
-		fmt.Sscanf(item.Statistics.ViewCount, "%d", &viewerCount)
+	if err := json.NewDecoder(resp.Body).Decode(&channelResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(channelResp.Items) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, channel)
 	}
 
-	isLive := item.Snippet.LiveBroadcastContent == "live"
+	item := channelResp.Items[0]
 
-	return &models.Streamer{
+	streamer := &models.Streamer{
 		ID:          item.ID,
 		Platform:    "youtube",
-		Username:    item.Snippet.ChannelID,
-		DisplayName: item.Snippet.ChannelTitle,
-		Title:       item.Snippet.Title,
+		Username:    item.ID,
+		DisplayName: item.Snippet.Title,
 		Thumbnail:   item.Snippet.Thumbnails.High.URL,
-		ViewerCount: viewerCount,
-		IsLive:      isLive,
-		EmbedURL:    fmt.Sprintf("https://www.youtube.com/embed/%s?autoplay=1", item.ID),
-		ChatURL:     fmt.Sprintf("https://www.youtube.com/live_chat?v=%s&embed_domain=localhost", item.ID),
-	}, nil
+		Title:       item.Snippet.Title,
+		IsLive:      false,
+		State:       models.StateOffline,
+	}
+
+	s.Observer.notify(*streamer)
+	return streamer, nil
+}
+
+// GetLiveStream gets the current live broadcast of a channel.
+// It returns nil without an error when the channel is not live.
+func (s *YouTubeService) GetLiveStream(ctx context.Context, channelID string) (*models.Streamer, error) {
+	live, err := s.searchVideos(ctx, YouTubeSearchOptions{EventType: "live", ChannelID: channelID, MaxResults: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(live) == 0 {
+		return nil, nil
+	}
+	return &live[0], nil
+}
+
+// YouTubeVideoCategory is a video category, such as Gaming (20)
+type YouTubeVideoCategory struct {
+	ID    string
+	Title string
+}
+
+// GetVideoCategories gets the assignable video categories of a region
+func (s *YouTubeService) GetVideoCategories(ctx context.Context, regionCode string) ([]YouTubeVideoCategory, error) {
+	if s.APIKey == "" {
+		return nil, fmt.Errorf("YouTube API key not configured")
+	}
+
+	categoriesURL := fmt.Sprintf(
+		"%s/videoCategories?part=snippet&regionCode=%s",
+		s.BaseURL,
+		url.QueryEscape(regionCode),
+	)
+
+	resp, err := s.get(ctx, "videoCategories", categoriesURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video categories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("YouTube API error: status %d - check API key and quota", resp.StatusCode)
+	}
+
+	var categoriesResp struct {
+		Items []struct {
+			ID      string `json:"id"`
+			Snippet struct {
+				Title      string `json:"title"`
+				Assignable bool   `json:"assignable"`
+			} `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&categoriesResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	categories := make([]YouTubeVideoCategory, 0, len(categoriesResp.Items))
+	for _, item := range categoriesResp.Items {
+		if item.Snippet.Assignable {
+			categories = append(categories, YouTubeVideoCategory{ID: item.ID, Title: item.Snippet.Title})
+		}
+	}
+	return categories, nil
+}
+
+// youtubeState derives a stream state from a video's broadcast content and
+// live streaming details
+func youtubeState(broadcastContent, actualStart, actualEnd string) string {
+	switch {
+	case broadcastContent == "live":
+		return models.StateLive
+	case broadcastContent == "upcoming":
+		return models.StateScheduled
+	case actualStart != "" || actualEnd != "":
+		// A finished broadcast rather than an ordinary upload
+		return models.StateEnded
+	default:
+		return models.StateVOD
+	}
+}
+
+// parseTime parses an RFC 3339 timestamp, returning nil when it is empty or invalid
+func parseTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	t = t.UTC()
+	return &t
 }
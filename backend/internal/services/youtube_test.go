@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"multistream/backend/internal/metrics"
+)
+
+// youtubeQuotaSpent reads the quota counted for an endpoint from /metrics
+func youtubeQuotaSpent(t *testing.T, endpoint string) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	prefix := `multistream_youtube_quota_units_total{endpoint="` + endpoint + `"} `
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), prefix); ok {
+			spent, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return spent
+		}
+	}
+	return 0
+}
+
+func TestYouTubeQuotaCountsEveryAttempt(t *testing.T) {
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt fails and is retried; both are charged
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"items": []}`))
+	}))
+	defer api.Close()
+
+	s := NewYouTubeService("test-key")
+	s.BaseURL = api.URL
+	NewResilience(2, time.Millisecond, 5, time.Minute).Wrap("youtube", s.Client)
+
+	before := youtubeQuotaSpent(t, "search")
+	if _, err := s.SearchLive(context.Background(), "speedrun", 5); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("YouTube was called %d times, want 2", calls.Load())
+	}
+	if got := youtubeQuotaSpent(t, "search") - before; got != 2*float64(youtubeQuotaCosts["search"]) {
+		t.Errorf("quota counted = %v, want %d for two searches", got, 2*youtubeQuotaCosts["search"])
+	}
+}
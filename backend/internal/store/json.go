@@ -0,0 +1,85 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONFile persists a single JSON document on disk
+type JSONFile struct {
+	Path string
+	// Compact skips indentation, for large machine-only documents
+	Compact bool
+
+	mu sync.Mutex
+}
+
+// NewJSONFile creates a JSON file store named name inside dir.
+// An empty dir disables persistence and makes Load and Save no-ops.
+func NewJSONFile(dir, name string) *JSONFile {
+	if dir == "" {
+		return &JSONFile{}
+	}
+	return &JSONFile{Path: filepath.Join(dir, name)}
+}
+
+// Load decodes the stored document into v. A missing file is not an error.
+func (f *JSONFile) Load(v interface{}) error {
+	if f.Path == "" {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// Save encodes v and atomically replaces the stored document
+func (f *JSONFile) Save(v interface{}) error {
+	if f.Path == "" {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	if f.Compact {
+		data, err = json.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", f.Path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", f.Path, err)
+	}
+	return nil
+}
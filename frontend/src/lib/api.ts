@@ -8,7 +8,14 @@ export interface Streamer {
     thumbnail: string;
     title: string;
     viewerCount: number;
+    language?: string;
+    category?: string;
+    verified?: boolean;
+    mature?: boolean;
     isLive: boolean;
+    state: "scheduled" | "live" | "ended" | "vod" | "offline";
+    scheduledStartTime?: string;
+    actualStartTime?: string;
     embedUrl?: string;
     chatUrl?: string;
 }
@@ -17,6 +24,8 @@ export interface SearchResponse {
     streamers: Streamer[];
     platform: string;
     query: string;
+    nextCursor?: string;
+    offline?: string[];
 }
 
 export interface StreamResponse {